@@ -0,0 +1,85 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// Avg calculate the average value of a column for records matching a query.
+func (r repository[m]) Avg(column string, where ...any) (float64, error) {
+	return r.aggregate("AVG", column, where...)
+}
+
+// Count the number of records matching a query.
+func (r repository[m]) Count(where ...any) (int64, error) {
+	var total int64
+
+	result := r.scope(where...).Count(&total)
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, "unable to count records")
+	}
+
+	return total, nil
+}
+
+// Exists determine whether any records match a query.
+func (r repository[m]) Exists(where ...any) (bool, error) {
+	found := make([]int, 0, 1)
+
+	result := r.scope(where...).Select("1").Limit(1).Scan(&found)
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "unable to determine whether records exist")
+	}
+
+	return len(found) > 0, nil
+}
+
+// Max calculate the maximum value of a column for records matching a query.
+func (r repository[m]) Max(column string, where ...any) (float64, error) {
+	return r.aggregate("MAX", column, where...)
+}
+
+// Min calculate the minimum value of a column for records matching a query.
+func (r repository[m]) Min(column string, where ...any) (float64, error) {
+	return r.aggregate("MIN", column, where...)
+}
+
+// Pluck fetch the values of a single column for records matching a query.
+func (r repository[m]) Pluck(column string, where ...any) ([]any, error) {
+	target, err := r.column(column)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]any, 0)
+
+	result := r.query(where...).Model(&r.model).Select("?", target).Scan(&values)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "unable to fetch column %s", column)
+	}
+
+	return values, nil
+}
+
+// Sum calculate the total value of a column for records matching a query.
+func (r repository[m]) Sum(column string, where ...any) (float64, error) {
+	return r.aggregate("SUM", column, where...)
+}
+
+// aggregate run an aggregate function against a column, no matching records will return zero.
+func (r repository[m]) aggregate(function string, column string, where ...any) (float64, error) {
+	target, err := r.column(column)
+	if err != nil {
+		return 0, err
+	}
+
+	var value sql.NullFloat64
+
+	result := r.scope(where...).Select(function+"(?)", target).Scan(&value)
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, "unable to calculate %s of %s", function, column)
+	}
+
+	return value.Float64, nil
+}
@@ -0,0 +1,157 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+func TestRepository_Aggregates(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 5))
+
+	testcases := map[string]struct {
+		aggregate func(column string, where ...any) (float64, error)
+		expected  float64
+		where     []any
+	}{
+		"avg": {
+			aggregate: instance.Avg,
+			expected:  3,
+		},
+		"max": {
+			aggregate: instance.Max,
+			expected:  5,
+		},
+		"min": {
+			aggregate: instance.Min,
+			expected:  1,
+		},
+		"sum": {
+			aggregate: instance.Sum,
+			expected:  15,
+		},
+		"sum with where": {
+			aggregate: instance.Sum,
+			expected:  6,
+			where:     []any{&modelmock.ModelMock{Test: false}, database.Or{&modelmock.ModelMock{ID: 2}, &modelmock.ModelMock{ID: 4}}},
+		},
+		"no results": {
+			aggregate: instance.Sum,
+			expected:  0,
+			where:     []any{database.Raw{Query: "id > ?", Parameters: []any{10}}},
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := testcase.aggregate("ID", testcase.where...)
+			require.NoError(t, err)
+
+			assert.InDelta(t, testcase.expected, actual, 0)
+		})
+	}
+}
+
+func TestRepository_Aggregates_ErrUnknownColumn(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 0))
+
+	actual, err := instance.Sum("unknown")
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unknown column: unknown")
+	assert.Zero(t, actual)
+
+	values, err := instance.Pluck("unknown")
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unknown column: unknown")
+	assert.Nil(t, values)
+}
+
+func TestRepository_Aggregates_QueryError(t *testing.T) {
+	t.Parallel()
+
+	connection := seed(t, 0)
+	require.NoError(t, connection.ORM().Migrator().DropTable(modelmock.ModelMock{}))
+
+	instance := database.Repository[modelmock.ModelMock](connection)
+
+	actual, err := instance.Max("id")
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to calculate MAX of id: SQL logic error: no such table: model_mocks (1)")
+	assert.Zero(t, actual)
+}
+
+func TestRepository_Count(t *testing.T) {
+	t.Parallel()
+
+	connection := seed(t, 5)
+	instance := database.Repository[modelmock.ModelMock](connection)
+
+	total, err := instance.Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(5), total)
+
+	total, err = instance.Count(&modelmock.ModelMock{Test: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), total)
+
+	// Deleted records are only counted when bypassing delete
+	err = instance.Delete(&modelmock.ModelMock{ID: 1})
+	require.NoError(t, err)
+
+	total, err = instance.Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(4), total)
+
+	total, err = instance.BypassDelete().Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(5), total)
+}
+
+func TestRepository_Exists(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 2))
+
+	exists, err := instance.Exists(&modelmock.ModelMock{ID: 2})
+	require.NoError(t, err)
+
+	assert.True(t, exists)
+
+	exists, err = instance.Exists(&modelmock.ModelMock{ID: 3})
+	require.NoError(t, err)
+
+	assert.False(t, exists)
+}
+
+func TestRepository_Pluck(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 3)).OrderBy(database.Order{Column: "id", Descending: true})
+
+	values, err := instance.Pluck("id")
+	require.NoError(t, err)
+
+	assert.Equal(t, []any{int64(3), int64(2), int64(1)}, values)
+
+	values, err = instance.Pluck("id", database.Raw{Query: "id > ?", Parameters: []any{10}})
+	require.NoError(t, err)
+
+	assert.Empty(t, values)
+}
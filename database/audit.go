@@ -0,0 +1,291 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// Audit options for recording an audit trail of repository writes.
+type Audit struct {
+	// Enabled record an audit entry for every record written by Create, CreateMany, Update, UpdateFields, Upsert,
+	// Delete and Restore, the audit table is created when connecting if it doesn't exist
+	Enabled bool
+	// Table name of the table audit entries are written to, defaults to audit_entries
+	Table string
+}
+
+// AuditAction type of write recorded by an audit entry.
+type AuditAction string
+
+// AuditChange old and new value of a column.
+type AuditChange struct {
+	New any `json:"new"`
+	Old any `json:"old"`
+}
+
+// AuditEntry a single write to a record.
+type AuditEntry struct {
+	Action AuditAction `gorm:"size:16"`
+	// Actor who made the change, taken from the context with Actor
+	Actor string `gorm:"size:191"`
+	// Changes JSON object of changed columns keyed by column name, each containing an AuditChange
+	Changes   string
+	CreatedAt time.Time
+	ID        int64 `gorm:"primaryKey"`
+	// PrimaryKey primary key of the record, composite keys are separated by commas
+	PrimaryKey string `gorm:"size:191;index:idx_audit_entity,priority:2"`
+	// Table name of the table the record belongs to
+	Table string `gorm:"size:191;index:idx_audit_entity,priority:1"`
+}
+
+// actorKey context key for actors.
+type actorKey struct{}
+
+const (
+	// AuditCreate a record was created.
+	AuditCreate AuditAction = "create"
+
+	// AuditDelete a record was deleted.
+	AuditDelete AuditAction = "delete"
+
+	// AuditRestore a deleted record was restored.
+	AuditRestore AuditAction = "restore"
+
+	// AuditUpdate a record was updated.
+	AuditUpdate AuditAction = "update"
+
+	// AuditUpsert a record was created or updated by an upsert.
+	AuditUpsert AuditAction = "upsert"
+)
+
+// defaultAuditTable table audit entries are written to when no table is configured.
+const defaultAuditTable = "audit_entries"
+
+// ErrAuditDisabled error returned when history is requested and auditing isn't enabled.
+var ErrAuditDisabled = errors.New("auditing isn't enabled")
+
+// Actor return the actor carried by ctx, an empty string is returned if there is no actor.
+func Actor(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	actor, _ := ctx.Value(actorKey{}).(string)
+
+	return actor
+}
+
+// WithActor return a copy of ctx carrying an actor, audit entries for writes made with the context record the actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Decode return the changed columns of an audit entry.
+func (a AuditEntry) Decode() (map[string]AuditChange, error) {
+	changes := make(map[string]AuditChange)
+
+	err := json.Unmarshal([]byte(a.Changes), &changes)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode audit changes")
+	}
+
+	return changes, nil
+}
+
+// History return the audit entries for a record, oldest first. The history of a tenant scoped record is only returned
+// while the record exists and belongs to the tenant.
+func (r repository[m]) History(model *m) ([]AuditEntry, error) {
+	if r.audit == nil {
+		return nil, ErrAuditDisabled
+	}
+
+	modelSchema, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := recordKey(r.connection.Statement.Context, modelSchema, model)
+	if !ok {
+		return nil, errors.New("model must have a primary key value to fetch history")
+	}
+
+	scope, err := r.tenancy()
+	if err != nil {
+		return nil, err
+	}
+
+	// History of tenant scoped records is only available to the tenant the record belongs to
+	if scope != nil {
+		found, err := r.visible(model)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			return nil, ErrNoResults
+		}
+	}
+
+	entries := make([]AuditEntry, 0)
+
+	query := r.connection.Session(&gorm.Session{NewDB: true}).Table(r.audit.Table) //nolint:exhaustruct // Default session
+	query = query.Where(map[string]any{"primary_key": key, "table": modelSchema.Table})
+
+	result := query.Order(clause.OrderByColumn{Column: clause.PrimaryColumn, Desc: false, Reorder: false}).Find(&entries)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "unable to fetch history")
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrNoResults
+	}
+
+	return entries, nil
+}
+
+// atomic run fn within a transaction when writes are audited, so audit entries are only kept if the write succeeds.
+// Transactions for writes which must not be applied twice aren't retried after a dropped connection.
+func (r repository[m]) atomic(once bool, fn func(tx repository[m]) error) error {
+	if r.audit == nil {
+		return fn(r)
+	}
+
+	retry := r.retry.do
+	if once {
+		retry = r.retry.doOnce
+	}
+
+	return retry(r.connection.Statement.Context, r.connection, "audited write", func() error {
+		var failure error
+
+		err := r.connection.Transaction(func(transaction *gorm.DB) error {
+			tx := r
+			tx.connection = transaction
+
+			failure = fn(tx)
+
+			return failure
+		})
+		if err != nil && failure == nil {
+			return errors.Wrap(translate(err), "unable to commit transaction")
+		}
+
+		return err //nolint:wrapcheck // Errors from fn are already wrapped
+	})
+}
+
+// record write audit entries for models, previous contains the stored version of each model before the write.
+func (r repository[m]) record(after Event, models []*m, previous []*m) error {
+	if r.audit == nil {
+		return nil
+	}
+
+	modelSchema, err := r.schema()
+	if err != nil {
+		return err
+	}
+
+	ctx := r.connection.Statement.Context
+	entries := make([]AuditEntry, 0, len(models))
+	now := time.Now().UTC()
+
+	for index, model := range models {
+		// Read the record back so the entry reflects what was stored, including defaults and soft deletes
+		current, err := r.stored(model)
+		if err != nil {
+			return err
+		}
+
+		changes, err := json.Marshal(diff(ctx, modelSchema, previous[index], current))
+		if err != nil {
+			return errors.Wrap(err, "unable to encode audit changes")
+		}
+
+		key, _ := recordKey(ctx, modelSchema, model)
+
+		entries = append(entries, AuditEntry{
+			Action:     AuditAction(strings.TrimPrefix(string(after), "after ")),
+			Actor:      Actor(ctx),
+			Changes:    string(changes),
+			CreatedAt:  now,
+			ID:         0,
+			PrimaryKey: key,
+			Table:      modelSchema.Table,
+		})
+	}
+
+	query := r.connection.Session(&gorm.Session{NewDB: true}).Table(r.audit.Table) //nolint:exhaustruct // Default session
+
+	result := query.Create(&entries)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "unable to write audit entries")
+	}
+
+	return nil
+}
+
+// diff compare the columns of two versions of a record, a nil version has a nil value for every column.
+func diff(ctx context.Context, modelSchema *schema.Schema, old any, current any) map[string]AuditChange {
+	changes := make(map[string]AuditChange)
+
+	for _, field := range modelSchema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+
+		change := AuditChange{New: column(ctx, field, current), Old: column(ctx, field, old)}
+
+		if !reflect.DeepEqual(change.Old, change.New) {
+			changes[field.DBName] = change
+		}
+	}
+
+	return changes
+}
+
+// column read the value of a field from a model, nil is returned if the model is nil or the value is a nil pointer.
+func column(ctx context.Context, field *schema.Field, model any) any {
+	reflected := reflect.ValueOf(model)
+	if !reflected.IsValid() || reflected.IsNil() {
+		return nil
+	}
+
+	value, _ := field.ValueOf(ctx, reflected.Elem())
+
+	if current := reflect.ValueOf(value); current.Kind() == reflect.Pointer && current.IsNil() {
+		return nil
+	}
+
+	return value
+}
+
+// recordKey format the primary key of a model, false is returned if the model has no primary key value.
+func recordKey(ctx context.Context, modelSchema *schema.Schema, model any) (string, bool) {
+	if len(modelSchema.PrimaryFields) == 0 {
+		return "", false
+	}
+
+	reflected := reflect.Indirect(reflect.ValueOf(model))
+	values := make([]string, 0, len(modelSchema.PrimaryFields))
+
+	for _, field := range modelSchema.PrimaryFields {
+		value, zero := field.ValueOf(ctx, reflected)
+		if zero {
+			return "", false
+		}
+
+		values = append(values, fmt.Sprint(value))
+	}
+
+	return strings.Join(values, ","), true
+}
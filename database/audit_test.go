@@ -0,0 +1,188 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+func TestActor(t *testing.T) {
+	t.Parallel()
+
+	ctx := database.WithActor(context.Background(), "user:42")
+
+	assert.Equal(t, "user:42", database.Actor(ctx))
+	assert.Empty(t, database.Actor(context.Background()))
+	assert.Empty(t, database.Actor(nil)) //nolint:staticcheck // Testing a nil context
+}
+
+func TestRepository_History(t *testing.T) {
+	t.Parallel()
+
+	connection := connectAudit(t, "")
+	repository := database.Repository[modelmock.ModelMock](connection).
+		WithContext(database.WithActor(context.Background(), "user:42"))
+
+	model := &modelmock.ModelMock{}
+	require.NoError(t, repository.Create(model))
+
+	model.Test = true
+	require.NoError(t, repository.Update(model))
+
+	require.NoError(t, repository.Delete(model))
+	require.NoError(t, repository.Restore(model))
+	require.NoError(t, repository.Upsert(&modelmock.ModelMock{ID: model.ID, Test: false}, []string{"ID"}, nil))
+
+	// Writes to other records aren't part of the history
+	require.NoError(t, database.Repository[modelmock.ModelMock](connection).Create(&modelmock.ModelMock{}))
+
+	history, err := repository.History(model)
+	require.NoError(t, err)
+
+	require.Len(t, history, 5)
+
+	actions := make([]database.AuditAction, 0, len(history))
+
+	for _, entry := range history {
+		actions = append(actions, entry.Action)
+
+		assert.Equal(t, "user:42", entry.Actor)
+		assert.Equal(t, "1", entry.PrimaryKey)
+		assert.Equal(t, "model_mocks", entry.Table)
+		assert.False(t, entry.CreatedAt.IsZero())
+	}
+
+	assert.Equal(
+		t,
+		[]database.AuditAction{
+			database.AuditCreate,
+			database.AuditUpdate,
+			database.AuditDelete,
+			database.AuditRestore,
+			database.AuditUpsert,
+		},
+		actions,
+	)
+
+	changes, err := history[0].Decode()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]database.AuditChange{
+		"id":   {New: float64(1), Old: nil},
+		"test": {New: false, Old: nil},
+	}, changes)
+
+	changes, err = history[1].Decode()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]database.AuditChange{"test": {New: true, Old: false}}, changes)
+
+	changes, err = history[2].Decode()
+	require.NoError(t, err)
+
+	require.Len(t, changes, 1)
+	assert.NotNil(t, changes["deleted_at"].New)
+	assert.Nil(t, changes["deleted_at"].Old)
+
+	changes, err = history[3].Decode()
+	require.NoError(t, err)
+
+	require.Len(t, changes, 1)
+	assert.Nil(t, changes["deleted_at"].New)
+	assert.NotNil(t, changes["deleted_at"].Old)
+
+	changes, err = history[4].Decode()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]database.AuditChange{"test": {New: false, Old: true}}, changes)
+}
+
+func TestRepository_History_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := database.Repository[modelmock.ModelMock](connectFile(t)).History(&modelmock.ModelMock{ID: 1})
+	require.ErrorIs(t, err, database.ErrAuditDisabled)
+
+	repository := database.Repository[modelmock.ModelMock](connectAudit(t, "changes"))
+
+	_, err = repository.History(&modelmock.ModelMock{})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "model must have a primary key value to fetch history")
+
+	_, err = repository.History(&modelmock.ModelMock{ID: 1})
+	require.ErrorIs(t, err, database.ErrNoResults)
+
+	_, err = repository.WithContext(cancelled()).History(&modelmock.ModelMock{ID: 1})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to fetch history: context canceled")
+}
+
+func TestRepository_History_Transaction(t *testing.T) {
+	t.Parallel()
+
+	connection := connectAudit(t, "changes")
+	repository := database.Repository[modelmock.ModelMock](connection)
+
+	// Audit entries are rolled back with the transaction
+	err := connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+		err := database.Repository[modelmock.ModelMock](tx).Create(&modelmock.ModelMock{})
+		require.NoError(t, err)
+
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+
+	model := &modelmock.ModelMock{}
+	require.NoError(t, repository.Create(model))
+
+	history, err := repository.History(model)
+	require.NoError(t, err)
+
+	assert.Len(t, history, 1)
+
+	// The write is rolled back if the audit entry can't be written
+	require.NoError(t, connection.ORM().Migrator().DropTable("changes"))
+
+	err = repository.Create(&modelmock.ModelMock{})
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "unable to write audit entries")
+
+	count, err := repository.Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), count)
+}
+
+// cancelled create a context which has been cancelled.
+func cancelled() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	return ctx
+}
+
+// connectAudit create a database which audits writes to a table.
+func connectAudit(t *testing.T, table string) *database.Database {
+	t.Helper()
+
+	connection, err := database.Connect(database.Config{
+		Audit:  database.Audit{Enabled: true, Table: table},
+		Driver: "sqlite",
+		Name:   t.TempDir() + "/audit.db",
+	})
+	require.NoError(t, err)
+
+	err = connection.Migrate(modelmock.ModelMock{})
+	require.NoError(t, err)
+
+	return connection
+}
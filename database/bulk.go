@@ -0,0 +1,306 @@
+package database
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+var (
+	// ErrMissingConditions error returned when a bulk update or delete is attempted without conditions.
+	ErrMissingConditions = errors.New("conditions must be provided for bulk updates and deletes")
+
+	// ErrTenantUpsert error returned when a tenant scoped upsert is attempted with a driver which can't limit the
+	// update to the tenant.
+	ErrTenantUpsert = errors.New("tenant scoped upserts are only supported by postgres and sqlite")
+)
+
+// CreateMany create records from models in batches, a batch size less than 1 will create all records at once.
+func (r repository[m]) CreateMany(models []m, batchSize int) error {
+	if len(models) == 0 {
+		return nil
+	}
+
+	if batchSize < 1 {
+		batchSize = len(models)
+	}
+
+	pointers := make([]*m, 0, len(models))
+	for index := range models {
+		pointers = append(pointers, &models[index])
+	}
+
+	return r.lifecycle(BeforeCreate, AfterCreate, pointers, func(tx repository[m]) error {
+		err := tx.assignTenant(pointers...)
+		if err != nil {
+			return err
+		}
+
+		result := tx.writeOnce("create records", func() *gorm.DB { return tx.connection.CreateInBatches(models, batchSize) })
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "unable to create records")
+		}
+
+		return nil
+	})
+}
+
+// DeleteWhere delete all records matching a query, returning the number of records deleted. Soft deletable records
+// are always soft deleted, even when deleted records are included, use ForceDelete or Purge to remove them permanently.
+func (r repository[m]) DeleteWhere(where ...any) (int64, error) {
+	if !r.hasConditions(where) {
+		return 0, ErrMissingConditions
+	}
+
+	query := r.conditions(where...)
+
+	// Start each attempt from a new statement so a retry doesn't reuse the failed attempt's transaction
+	query = query.Session(&gorm.Session{}) //nolint:exhaustruct // Default session
+
+	result := r.write("delete records", func() *gorm.DB { return query.Delete(&r.model) })
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrMissingWhereClause) {
+			return 0, ErrMissingConditions
+		}
+
+		return 0, errors.Wrap(result.Error, "unable to delete records")
+	}
+
+	return result.RowsAffected, nil
+}
+
+// UpdateFields update only the specified fields of a record from a model, versioned models also update the version
+// and return ErrConflict if the record was changed since the model was read.
+func (r repository[m]) UpdateFields(model *m, fields ...string) error {
+	if len(fields) == 0 {
+		return errors.New("at least one field must be provided")
+	}
+
+	columns := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		column, err := r.column(field)
+		if err != nil {
+			return err
+		}
+
+		columns = append(columns, column.Name)
+	}
+
+	return r.lifecycle(BeforeUpdate, AfterUpdate, []*m{model}, func(tx repository[m]) error {
+		result := tx.writeOnce("update record", func() *gorm.DB {
+			return tx.save(model, columns...)
+		})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "unable to update record")
+		}
+
+		return nil
+	})
+}
+
+// UpdateWhere update all records matching a query with values, returning the number of records updated.
+func (r repository[m]) UpdateWhere(values map[string]any, where ...any) (int64, error) {
+	if !r.hasConditions(where) {
+		return 0, ErrMissingConditions
+	}
+
+	query := r.conditions(where...).Model(&r.model)
+	if r.unscoped {
+		query = query.Unscoped()
+	}
+
+	// Start each attempt from a new statement so a retry doesn't reuse the failed attempt's transaction
+	query = query.Session(&gorm.Session{}) //nolint:exhaustruct // Default session
+
+	write := r.write
+
+	// Expressions such as incrementing a column change records again if they are repeated
+	for _, value := range values {
+		if _, ok := value.(clause.Expression); ok {
+			write = r.writeOnce
+
+			break
+		}
+	}
+
+	result := write("update records", func() *gorm.DB { return query.Updates(values) })
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrMissingWhereClause) {
+			return 0, ErrMissingConditions
+		}
+
+		return 0, errors.Wrap(result.Error, "unable to update records")
+	}
+
+	return result.RowsAffected, nil
+}
+
+// Upsert create a record from a model, or update it if it conflicts with an existing record. If no update columns
+// are provided every column will be updated. MySQL uses any unique key to detect conflicts so conflict columns are
+// only used to build the statement for other drivers. Tenant scoped upserts never change the tenant of a record and
+// return ErrTenantMismatch if the conflicting record belongs to another tenant, they are only supported by postgres
+// and sqlite since other drivers can't limit the update to the tenant.
+func (r repository[m]) Upsert(model *m, conflictColumns []string, updateColumns []string) error {
+	conflict := clause.OnConflict{
+		Columns:      make([]clause.Column, 0, len(conflictColumns)),
+		Where:        clause.Where{Exprs: nil},
+		TargetWhere:  clause.Where{Exprs: nil},
+		OnConstraint: "",
+		DoNothing:    false,
+		DoUpdates:    nil,
+		UpdateAll:    false,
+	}
+
+	for _, name := range conflictColumns {
+		column, err := r.column(name)
+		if err != nil {
+			return err
+		}
+
+		conflict.Columns = append(conflict.Columns, clause.Column{Alias: "", Name: column.Name, Raw: false, Table: ""})
+	}
+
+	columns := make([]string, 0, len(updateColumns))
+
+	for _, name := range updateColumns {
+		column, err := r.column(name)
+		if err != nil {
+			return err
+		}
+
+		columns = append(columns, column.Name)
+	}
+
+	scope, err := r.tenancy()
+	if err != nil {
+		return err
+	}
+
+	if scope != nil {
+		err = r.guardUpsert(&conflict, scope, columns)
+		if err != nil {
+			return err
+		}
+	} else if len(columns) > 0 {
+		conflict.DoUpdates = clause.AssignmentColumns(columns)
+	} else {
+		conflict.UpdateAll = true
+	}
+
+	return r.lifecycle(BeforeUpsert, AfterUpsert, []*m{model}, func(tx repository[m]) error {
+		err := scope.assign(tx.connection.Statement.Context, model)
+		if err != nil {
+			return err
+		}
+
+		result := tx.writeOnce("upsert record", func() *gorm.DB { return tx.connection.Clauses(conflict).Create(model) })
+		if result.Error == nil && scope != nil && result.RowsAffected == 0 {
+			_ = result.AddError(ErrTenantMismatch)
+		}
+
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "unable to upsert record")
+		}
+
+		return nil
+	})
+}
+
+// guardUpsert limit the update of a tenant scoped upsert to records belonging to the tenant, the tenant column is
+// never updated. If no columns are provided every column other than the tenant column is updated.
+func (r repository[m]) guardUpsert(conflict *clause.OnConflict, scope *tenancy, columns []string) error {
+	if name := r.connection.Name(); name != "postgres" && name != "sqlite" {
+		return ErrTenantUpsert
+	}
+
+	modelSchema, err := r.schema()
+	if err != nil {
+		return err
+	}
+
+	if len(columns) == 0 {
+		for _, field := range modelSchema.Fields {
+			if upsertable(field) {
+				columns = append(columns, field.DBName)
+			}
+		}
+	}
+
+	updates := make([]string, 0, len(columns))
+
+	for _, column := range columns {
+		if column != scope.field.DBName {
+			updates = append(updates, column)
+		}
+	}
+
+	// Updating nothing would skip the update for the tenant too, the guarded tenant column is unchanged by the update
+	if len(updates) == 0 {
+		updates = append(updates, scope.field.DBName)
+	}
+
+	conflict.DoUpdates = clause.AssignmentColumns(updates)
+	conflict.Where = clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: currentColumn(scope.field), Value: scope.tenant},
+	}}
+
+	return nil
+}
+
+// hasConditions determine whether where contains at least one condition which adds an expression to the query.
+func (r repository[m]) hasConditions(where []any) bool {
+	for _, condition := range where {
+		expression, err := r.condition(condition)
+
+		// Invalid conditions are reported when the query is built
+		if err != nil || !empty(expression) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// empty determine whether an expression has nothing to add to a query.
+func empty(expression clause.Expression) bool {
+	switch state := expression.(type) {
+	case nil:
+		return true
+	case clause.AndConditions:
+		return allEmpty(state.Exprs)
+	case clause.OrConditions:
+		return allEmpty(state.Exprs)
+	case clause.NotConditions:
+		return allEmpty(state.Exprs)
+	case clause.Expr:
+		return strings.TrimSpace(state.SQL) == ""
+	default:
+		return false
+	}
+}
+
+// allEmpty determine whether every expression has nothing to add to a query.
+func allEmpty(expressions []clause.Expression) bool {
+	for _, expression := range expressions {
+		if !empty(expression) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// upsertable determine whether a field is updated when an upsert updates every column, matching the columns gorm
+// updates for an upsert without update columns.
+func upsertable(field *schema.Field) bool {
+	if field.DBName == "" || field.PrimaryKey || field.AutoCreateTime != 0 || !field.Creatable || !field.Updatable {
+		return false
+	}
+
+	return !field.HasDefaultValue || field.DefaultValueInterface != nil || strings.EqualFold(field.DefaultValue, "NULL")
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/database/drivers"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+func TestRepository_Upsert_Dialects(t *testing.T) {
+	t.Parallel()
+
+	sqliteDialector, err := drivers.SQLite3{Filename: ":memory:"}.GetDialector()
+	require.NoError(t, err)
+
+	testcases := map[string]struct {
+		dialector gorm.Dialector
+		expected  string
+	}{
+		"mysql": {
+			dialector: mysql.New(mysql.Config{DSN: "root@/test", SkipInitializeWithVersion: true}),
+			expected:  "ON DUPLICATE KEY UPDATE `test`=VALUES(`test`)",
+		},
+		"postgres": {
+			dialector: postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}),
+			expected:  `ON CONFLICT ("id") DO UPDATE SET "test"="excluded"."test"`,
+		},
+		"sqlite": {
+			dialector: sqliteDialector,
+			expected:  "ON CONFLICT (`id`) DO UPDATE SET `test`=`excluded`.`test`",
+		},
+		"sqlserver": {
+			dialector: sqlserver.New(sqlserver.Config{DSN: "sqlserver://localhost?database=test"}),
+			expected:  `MERGE INTO "model_mocks" USING`,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			orm, err := gorm.Open(testcase.dialector, &gorm.Config{DisableAutomaticPing: true, DryRun: true, SkipDefaultTransaction: true})
+			require.NoError(t, err)
+
+			var statement string
+
+			err = orm.Callback().Create().Register("test:capture", func(db *gorm.DB) {
+				statement = db.Statement.SQL.String()
+			})
+			require.NoError(t, err)
+
+			instance := Repository[modelmock.ModelMock](&Database{orm: orm})
+
+			err = instance.Upsert(&modelmock.ModelMock{ID: 1, Test: true}, []string{"id"}, []string{"Test"})
+			require.NoError(t, err)
+
+			assert.Contains(t, statement, testcase.expected)
+		})
+	}
+}
+
+func TestRepository_Upsert_TenantDialects(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		dialector gorm.Dialector
+		err       error
+		expected  string
+	}{
+		"mysql": {
+			dialector: mysql.New(mysql.Config{DSN: "root@/test", SkipInitializeWithVersion: true}),
+			err:       ErrTenantUpsert,
+		},
+		"postgres": {
+			dialector: postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}),
+			expected:  `ON CONFLICT ("id") DO UPDATE SET "paid"="excluded"."paid" WHERE "invoices"."tenant_id" = $4`,
+		},
+		"sqlserver": {
+			dialector: sqlserver.New(sqlserver.Config{DSN: "sqlserver://localhost?database=test"}),
+			err:       ErrTenantUpsert,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			orm, err := gorm.Open(testcase.dialector, &gorm.Config{DisableAutomaticPing: true, DryRun: true, SkipDefaultTransaction: true})
+			require.NoError(t, err)
+
+			var statement string
+
+			err = orm.Callback().Create().Register("test:capture", func(db *gorm.DB) {
+				statement = db.Statement.SQL.String()
+			})
+			require.NoError(t, err)
+
+			instance := Repository[invoice](&Database{orm: orm}).WithContext(WithTenant(context.Background(), "acme"))
+
+			err = instance.Upsert(&invoice{ID: 1, Paid: true}, []string{"ID"}, nil)
+			if testcase.err != nil {
+				require.ErrorIs(t, err, testcase.err)
+
+				return
+			}
+
+			// Dry runs don't affect any rows
+			require.ErrorIs(t, err, ErrTenantMismatch)
+			assert.Contains(t, statement, testcase.expected)
+		})
+	}
+}
+
+// invoice model scoped to a tenant.
+type invoice struct {
+	ID       int
+	Paid     bool
+	TenantID string `gorm:"tenant"`
+}
+
+// TableName return the database table for this model.
+func (i invoice) TableName() string {
+	return "invoices"
+}
@@ -0,0 +1,235 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+func TestRepository_CreateMany(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		batchSize int
+	}{
+		"batched":   {batchSize: 2},
+		"unbatched": {batchSize: 0},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			instance := database.Repository[modelmock.ModelMock](connectFile(t))
+
+			models := make([]modelmock.ModelMock, 5)
+
+			err := instance.CreateMany(models, testcase.batchSize)
+			require.NoError(t, err)
+
+			assert.Equal(t, []int{1, 2, 3, 4, 5}, ids(models))
+
+			count, err := instance.Count()
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(5), count)
+		})
+	}
+}
+
+func TestRepository_CreateMany_Empty(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](connectFile(t))
+
+	err := instance.CreateMany(nil, 10)
+	require.NoError(t, err)
+}
+
+func TestRepository_DeleteWhere(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 5))
+
+	deleted, err := instance.DeleteWhere(&modelmock.ModelMock{Test: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), deleted)
+
+	remaining, err := instance.Get()
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{2, 4}, ids(remaining))
+
+	// Including deleted records doesn't permanently delete them, records which are already deleted are left alone
+	deleted, err = instance.BypassDelete().DeleteWhere(database.Raw{Query: "id < ?", Parameters: []any{3}})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), deleted)
+
+	deleted, err = instance.OnlyDeleted().DeleteWhere(database.Gt("id", 0))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(0), deleted)
+
+	count, err := instance.BypassDelete().Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(5), count)
+}
+
+func TestRepository_UpdateFields(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 1))
+
+	err := instance.UpdateFields(&modelmock.ModelMock{ID: 1, Test: false}, "Test")
+	require.NoError(t, err)
+
+	model, err := instance.One(&modelmock.ModelMock{ID: 1})
+	require.NoError(t, err)
+
+	assert.False(t, model.Test)
+}
+
+func TestRepository_UpdateWhere(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 5))
+
+	updated, err := instance.UpdateWhere(map[string]any{"test": true}, database.Raw{Query: "id > ?", Parameters: []any{2}})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), updated)
+
+	count, err := instance.Count(&modelmock.ModelMock{Test: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(4), count)
+}
+
+func TestRepository_Upsert(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 1))
+
+	err := instance.Upsert(&modelmock.ModelMock{ID: 1, Test: false}, []string{"ID"}, []string{"Test"})
+	require.NoError(t, err)
+
+	err = instance.Upsert(&modelmock.ModelMock{ID: 2, Test: true}, []string{"ID"}, nil)
+	require.NoError(t, err)
+
+	models, err := instance.Get()
+	require.NoError(t, err)
+
+	require.Len(t, models, 2)
+	assert.False(t, models[0].Test)
+	assert.True(t, models[1].Test)
+}
+
+func TestRepository_Bulk_Errors(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 1))
+
+	testcases := map[string]struct {
+		call     func() error
+		expected string
+	}{
+		"delete without conditions": {
+			call: func() error {
+				_, err := instance.DeleteWhere()
+
+				return err
+			},
+			expected: database.ErrMissingConditions.Error(),
+		},
+		"delete with nil condition": {
+			call: func() error {
+				_, err := instance.DeleteWhere(nil)
+
+				return err
+			},
+			expected: database.ErrMissingConditions.Error(),
+		},
+		"delete with empty map": {
+			call: func() error {
+				_, err := instance.DeleteWhere(map[string]any{})
+
+				return err
+			},
+			expected: database.ErrMissingConditions.Error(),
+		},
+		"delete with empty and": {
+			call: func() error {
+				_, err := instance.BypassDelete().DeleteWhere(database.And())
+
+				return err
+			},
+			expected: database.ErrMissingConditions.Error(),
+		},
+		"delete with nested empty filters": {
+			call: func() error {
+				_, err := instance.DeleteWhere(database.Or{database.And(), map[string]any{}}, database.Raw{Query: ""})
+
+				return err
+			},
+			expected: database.ErrMissingConditions.Error(),
+		},
+		"update fields without fields": {
+			call: func() error {
+				return instance.UpdateFields(&modelmock.ModelMock{ID: 1})
+			},
+			expected: "at least one field must be provided",
+		},
+		"update fields unknown column": {
+			call: func() error {
+				return instance.UpdateFields(&modelmock.ModelMock{ID: 1}, "Missing")
+			},
+			expected: "unknown column: Missing",
+		},
+		"update without conditions": {
+			call: func() error {
+				_, err := instance.UpdateWhere(map[string]any{"test": true})
+
+				return err
+			},
+			expected: database.ErrMissingConditions.Error(),
+		},
+		"update with empty or": {
+			call: func() error {
+				_, err := instance.UpdateWhere(map[string]any{"test": true}, database.Or{})
+
+				return err
+			},
+			expected: database.ErrMissingConditions.Error(),
+		},
+		"upsert unknown conflict column": {
+			call: func() error {
+				return instance.Upsert(&modelmock.ModelMock{}, []string{"Missing"}, nil)
+			},
+			expected: "unknown column: Missing",
+		},
+		"upsert unknown update column": {
+			call: func() error {
+				return instance.Upsert(&modelmock.ModelMock{}, []string{"ID"}, []string{"Missing"})
+			},
+			expected: "unknown column: Missing",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := testcase.call()
+			require.Error(t, err)
+
+			require.EqualError(t, err, testcase.expected)
+		})
+	}
+}
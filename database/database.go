@@ -1,26 +1,41 @@
 package database
 
 import (
-	"strings"
+	"context"
+	"database/sql"
+	"sync/atomic"
 
 	"github.com/carlmjohnson/truthy"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 
-	"github.com/sjdaws/pkg/database/drivers"
 	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/logging"
 )
 
 // Connection interface.
 type Connection interface {
+	Close() error
 	Migrate(model ...any) error
+	MigrateContext(ctx context.Context, model ...any) error
 	ORM() *gorm.DB
+	Metrics() []Metric
+	Ping(ctx context.Context) error
+	Plan(ctx context.Context, model ...any) (*Plan, error)
+	Stats() sql.DBStats
 	Transaction() *gorm.DB
+	WithTransaction(ctx context.Context, fn func(tx Connection) error) error
 }
 
 // Database instance.
 type Database struct {
-	orm *gorm.DB
+	audit    *Audit
+	hooks    *Hooks
+	logger   logging.Logger
+	metrics  *metrics
+	orm      *gorm.DB
+	pending  *pending
+	replicas []*gorm.DB
+	retry    *retrier
 }
 
 // Driver interface.
@@ -28,76 +43,116 @@ type Driver interface {
 	GetDialector() (gorm.Dialector, error)
 }
 
-// Connect create a new database connection.
-func Connect(
-	debug bool,
-	driver string,
-	host string,
-	name string,
-	password string,
-	port int,
-	socket string,
-	sslmode string,
-	username string,
-) (*Database, error) {
-	var options Driver
-
-	switch strings.ToLower(driver) {
-	case "mariadb", "mysql":
-		options = drivers.MySQL{
-			Host:     host,
-			Name:     name,
-			Password: password,
-			Port:     port,
-			Socket:   socket,
-			Username: username,
-		}
-	case "postgres", "postgresql":
-		options = drivers.PostgreSQL{
-			Host:            host,
-			Name:            name,
-			Password:        password,
-			Port:            port,
-			SSLModeDisabled: strings.EqualFold(sslmode, "disabled"),
-			Username:        username,
-		}
-	case "sqlite", "sqlite3":
-		options = drivers.SQLite3{
-			Filename: name,
-		}
-	case "sqlserver":
-		options = drivers.SQLServer{
-			Host:     host,
-			Name:     name,
-			Password: password,
-			Port:     port,
-			Username: username,
-		}
-	default:
-		return nil, errors.New("unsupported database type requested: %s", driver)
+// Connect create a new database connection using a registered driver.
+func Connect(config Config) (*Database, error) {
+	factory, ok := lookupDriver(config.Driver)
+	if !ok {
+		return nil, errors.New("unsupported database type requested: %s", config.Driver)
+	}
+
+	driver, err := factory(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create driver")
 	}
 
-	dialector, err := options.GetDialector()
+	return ConnectWithDriver(driver, config)
+}
+
+// ConnectWithDriver create a new database connection using a driver directly, the driver in config isn't used for the
+// primary. Replicas are always connected using the registered driver named by the driver in config, so it must be set
+// to a registered driver when replicas are provided.
+func ConnectWithDriver(driver Driver, config Config) (*Database, error) {
+	if driver == nil {
+		return nil, errors.New("driver must be provided")
+	}
+
+	dialector, err := driver.GetDialector()
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create dialector")
 	}
 
-	orm, err := gorm.Open(dialector, createConfiguration(truthy.Cond(debug, logger.Info, logger.Warn)))
+	orm, err := gorm.Open(dialector, createConfiguration(config))
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to open connection to database")
+		return nil, errors.Wrap(translate(err), "unable to open connection to database")
+	}
+
+	err = config.Pool.configure(orm)
+	if err != nil {
+		closeAll([]*gorm.DB{orm})
+
+		return nil, err
+	}
+
+	err = orm.Use(errorTranslator{})
+	if err != nil {
+		closeAll([]*gorm.DB{orm})
+
+		return nil, errors.Wrap(err, "unable to configure database")
+	}
+
+	collected := newMetrics()
+
+	err = orm.Use(collector{exporter: config.Exporter, metrics: collected})
+	if err != nil {
+		closeAll([]*gorm.DB{orm})
+
+		return nil, errors.Wrap(err, "unable to configure database")
+	}
+
+	replicas, err := connectReplicas(config)
+	if err != nil {
+		closeAll([]*gorm.DB{orm})
+
+		return nil, err
+	}
+
+	if len(replicas) > 0 {
+		pools := make([]gorm.ConnPool, 0, len(replicas))
+		for _, replica := range replicas {
+			pools = append(pools, replica.ConnPool)
+		}
+
+		err = orm.Use(resolver{counter: &atomic.Uint64{}, replicas: pools})
+		if err != nil {
+			closeAll(append(replicas, orm))
+
+			return nil, errors.Wrap(err, "unable to configure replicas")
+		}
+	}
+
+	instance := &Database{
+		audit:    nil,
+		hooks:    NewHooks(),
+		logger:   truthy.Cond[logging.Logger](config.Logger == nil, logging.Default(), config.Logger),
+		metrics:  collected,
+		orm:      orm,
+		pending:  nil,
+		replicas: replicas,
+		retry:    newRetrier(config),
+	}
+
+	if config.Audit.Enabled {
+		instance.audit = &Audit{Enabled: true, Table: truthy.Cond(config.Audit.Table == "", defaultAuditTable, config.Audit.Table)}
+
+		err = instance.migrator(context.Background()).Table(instance.audit.Table).AutoMigrate(&AuditEntry{})
+		if err != nil {
+			closeAll(append(replicas, orm))
+
+			return nil, errors.Wrap(err, "unable to create audit table")
+		}
 	}
 
-	return &Database{orm: orm}, nil
+	return instance, nil
 }
 
 // Migrate run database migrations.
 func (d *Database) Migrate(model ...any) error {
-	// Force InnoDB for MySQL-like DBs
-	if d.orm.Name() == "mysql" {
-		d.orm.Set("gorm:table_options", "ENGINE=InnoDB")
-	}
+	return d.MigrateContext(context.Background(), model...)
+}
 
-	err := d.orm.AutoMigrate(model...)
+// MigrateContext run database migrations using a context.
+func (d *Database) MigrateContext(ctx context.Context, model ...any) error {
+	err := d.migrator(ctx).AutoMigrate(model...)
 	if err != nil {
 		return errors.Wrap(err, "unable to invoke database migrations")
 	}
@@ -115,8 +170,71 @@ func (d *Database) Transaction() *gorm.DB {
 	return d.orm.Begin()
 }
 
+// WithTransaction run fn within a transaction, the transaction is committed if fn returns nil and rolled back if fn
+// returns an error or panics. Repositories created from tx are automatically part of the transaction, and calling
+// WithTransaction on tx will create a savepoint. Changes are only published to listeners once the outermost
+// transaction commits. If a retry policy is configured the transaction is run again when it
+// fails with a transient error, so fn must be safe to run more than once.
+func (d *Database) WithTransaction(ctx context.Context, fn func(tx Connection) error) error {
+	return d.retry.do(ctx, d.orm, "transaction", func() error {
+		return d.transaction(ctx, fn)
+	})
+}
+
+// transaction run fn within a single transaction attempt.
+func (d *Database) transaction(ctx context.Context, fn func(tx Connection) error) error {
+	var (
+		failure error
+		started bool
+	)
+
+	changes := newPending()
+
+	err := d.orm.WithContext(ctx).Transaction(func(transaction *gorm.DB) error {
+		started = true
+		failure = fn(&Database{
+			audit:    d.audit,
+			hooks:    d.hooks,
+			logger:   d.logger,
+			metrics:  d.metrics,
+			orm:      transaction,
+			pending:  changes,
+			replicas: nil,
+			retry:    d.retry,
+		})
+
+		return failure
+	})
+
+	switch {
+	case err == nil:
+		changes.flush(d.pending)
+
+		return nil
+	case !started:
+		return errors.Wrap(translate(err), "unable to begin transaction")
+	case failure != nil:
+		return failure
+	default:
+		return errors.Wrap(translate(err), "unable to commit transaction")
+	}
+}
+
+// migrator configure the ORM for migrations.
+func (d *Database) migrator(ctx context.Context) *gorm.DB {
+	// Always inspect the schema on the primary, replicas may lag behind
+	orm := UsePrimary(d.orm.WithContext(ctx))
+
+	// Force InnoDB for MySQL-like DBs
+	if orm.Name() == "mysql" {
+		orm = orm.Set("gorm:table_options", "ENGINE=InnoDB")
+	}
+
+	return orm
+}
+
 // createConfiguration creates a configuration for a dialector.
-func createConfiguration(logMode logger.LogLevel) *gorm.Config {
+func createConfiguration(config Config) *gorm.Config {
 	return &gorm.Config{
 		AllowGlobalUpdate:                        false,
 		ClauseBuilders:                           nil,
@@ -129,7 +247,7 @@ func createConfiguration(logMode logger.LogLevel) *gorm.Config {
 		DryRun:                                   false,
 		FullSaveAssociations:                     false,
 		IgnoreRelationshipsWhenMigrating:         false,
-		Logger:                                   logger.Default.LogMode(logMode),
+		Logger:                                   newLogger(config),
 		NamingStrategy:                           nil,
 		NowFunc:                                  nil,
 		Plugins:                                  nil,
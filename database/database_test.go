@@ -1,6 +1,7 @@
 package database_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,13 +9,14 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/errors"
 	"github.com/sjdaws/pkg/testing/database/modelmock"
 )
 
 func TestConnect(t *testing.T) {
 	t.Parallel()
 
-	connection, err := database.Connect(false, "sqlite", "", ":memory:", "", 0, "", "", "")
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: ":memory:"})
 	require.NoError(t, err)
 
 	assert.IsType(t, &database.Database{}, connection)
@@ -38,7 +40,7 @@ func TestConnect_ErrInvalidDialector(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			connection, err := database.Connect(false, name, "", "", "", 0, "", "", "")
+			connection, err := database.Connect(database.Config{Driver: name})
 			require.Error(t, err)
 
 			require.EqualError(t, err, "unable to create dialector: database name must be provided")
@@ -50,7 +52,7 @@ func TestConnect_ErrInvalidDialector(t *testing.T) {
 func TestConnect_ErrInvalidDriver(t *testing.T) {
 	t.Parallel()
 
-	connection, err := database.Connect(false, "invalid", "", "", "", 0, "", "", "")
+	connection, err := database.Connect(database.Config{Driver: "invalid"})
 	require.Error(t, err)
 
 	require.EqualError(t, err, "unsupported database type requested: invalid")
@@ -60,7 +62,7 @@ func TestConnect_ErrInvalidDriver(t *testing.T) {
 func TestConnect_ErrOpenDatabaseFailure(t *testing.T) {
 	t.Parallel()
 
-	connection, err := database.Connect(false, "sqlite", "", "\\/:", "", 0, "", "", "")
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: "\\/:"})
 	require.Error(t, err)
 
 	require.EqualError(t, err, "unable to open connection to database: unable to open database file: out of memory (14)")
@@ -71,7 +73,7 @@ func TestConnect_ErrMigrationFailure(t *testing.T) {
 	t.Parallel()
 
 	// Enforce read only database
-	connection, err := database.Connect(false, "sqlite", "", ":memory:?_pragma=query_only(true)", "", 0, "", "", "")
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: ":memory:?_pragma=query_only(true)"})
 	require.NoError(t, err)
 
 	err = connection.Migrate(modelmock.ModelMock{})
@@ -80,10 +82,25 @@ func TestConnect_ErrMigrationFailure(t *testing.T) {
 	require.EqualError(t, err, "unable to invoke database migrations: attempt to write a readonly database (8)")
 }
 
+func TestConnection_MigrateContext(t *testing.T) {
+	t.Parallel()
+
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: ":memory:"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = connection.MigrateContext(ctx, modelmock.ModelMock{})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to invoke database migrations: context canceled")
+}
+
 func TestConnection_ORM(t *testing.T) {
 	t.Parallel()
 
-	connection, err := database.Connect(false, "sqlite", "", ":memory:", "", 0, "", "", "")
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: ":memory:"})
 	require.NoError(t, err)
 
 	assert.IsType(t, &gorm.DB{}, connection.ORM())
@@ -92,7 +109,7 @@ func TestConnection_ORM(t *testing.T) {
 func TestConnection_Repository(t *testing.T) {
 	t.Parallel()
 
-	connection, err := database.Connect(false, "sqlite", "", ":memory:", "", 0, "", "", "")
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: ":memory:"})
 	require.NoError(t, err)
 
 	instance := database.Repository[modelmock.ModelMock](connection)
@@ -103,7 +120,7 @@ func TestConnection_Repository(t *testing.T) {
 func TestConnection_Transaction(t *testing.T) {
 	t.Parallel()
 
-	connection, err := database.Connect(false, "sqlite", "", ":memory:", "", 0, "", "", "")
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: ":memory:"})
 	require.NoError(t, err)
 
 	transaction := connection.Transaction()
@@ -111,3 +128,114 @@ func TestConnection_Transaction(t *testing.T) {
 	assert.NotEqual(t, connection, transaction)
 	assert.IsType(t, &gorm.DB{}, transaction)
 }
+
+func TestConnection_WithTransaction(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+
+	err := connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+		return database.Repository[modelmock.ModelMock](tx).Create(&modelmock.ModelMock{Test: true})
+	})
+	require.NoError(t, err)
+
+	result, err := database.Repository[modelmock.ModelMock](connection).Get()
+	require.NoError(t, err)
+
+	assert.Len(t, result, 1)
+}
+
+func TestConnection_WithTransaction_Error(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+
+	err := connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+		err := database.Repository[modelmock.ModelMock](tx).Create(&modelmock.ModelMock{Test: true})
+		require.NoError(t, err)
+
+		return errors.New("test")
+	})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "test")
+
+	_, err = database.Repository[modelmock.ModelMock](connection).Get()
+	require.ErrorIs(t, err, database.ErrNoResults)
+}
+
+func TestConnection_WithTransaction_ErrBegin(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := connection.WithTransaction(ctx, func(_ database.Connection) error {
+		return nil
+	})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to begin transaction: context canceled")
+}
+
+func TestConnection_WithTransaction_Nested(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+
+	err := connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+		err := database.Repository[modelmock.ModelMock](tx).Create(&modelmock.ModelMock{Test: true})
+		require.NoError(t, err)
+
+		// Inner transaction is rolled back to a savepoint, outer transaction is unaffected
+		err = tx.WithTransaction(context.Background(), func(nested database.Connection) error {
+			err := database.Repository[modelmock.ModelMock](nested).Create(&modelmock.ModelMock{Test: false})
+			require.NoError(t, err)
+
+			return errors.New("nested")
+		})
+		require.EqualError(t, err, "nested")
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	result, err := database.Repository[modelmock.ModelMock](connection).Get()
+	require.NoError(t, err)
+
+	require.Len(t, result, 1)
+	assert.True(t, result[0].Test)
+}
+
+func TestConnection_WithTransaction_Panic(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+
+	assert.PanicsWithValue(t, "test", func() {
+		_ = connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+			err := database.Repository[modelmock.ModelMock](tx).Create(&modelmock.ModelMock{Test: true})
+			require.NoError(t, err)
+
+			panic("test")
+		})
+	})
+
+	_, err := database.Repository[modelmock.ModelMock](connection).Get()
+	require.ErrorIs(t, err, database.ErrNoResults)
+}
+
+// connectFile connect to a file based database which persists across connections and migrate the mock model.
+func connectFile(t *testing.T) *database.Database {
+	t.Helper()
+
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: t.TempDir() + "/test.db"})
+	require.NoError(t, err)
+
+	err = connection.Migrate(modelmock.ModelMock{})
+	require.NoError(t, err)
+
+	return connection
+}
@@ -17,6 +17,7 @@ type MySQL struct {
 	Password string
 	Port     int
 	Socket   string
+	TLS      TLS
 	Username string
 }
 
@@ -49,6 +50,15 @@ func (d MySQL) GetDialector() (gorm.Dialector, error) {
 		d.Name,
 	)
 
+	encryption, err := d.tlsParameter()
+	if err != nil {
+		return nil, err
+	}
+
+	if encryption != "" {
+		dsn += "&tls=" + encryption
+	}
+
 	dsnConfig, _ := base.ParseDSN(dsn)
 
 	return mysql.Dialector{
@@ -72,3 +82,38 @@ func (d MySQL) GetDialector() (gorm.Dialector, error) {
 		},
 	}, nil
 }
+
+// tlsParameter determine the value for the tls dsn parameter, registering a custom tls configuration if required.
+func (d MySQL) tlsParameter() (string, error) {
+	mode, err := d.TLS.mode()
+	if err != nil {
+		return "", err
+	}
+
+	custom := d.TLS.CA != "" || d.TLS.clientCertificate() || d.TLS.ServerName != ""
+
+	switch {
+	case mode == "":
+		return "", nil
+	case mode == TLSDisable:
+		return "false", nil
+	case mode == TLSRequire && !custom:
+		return "skip-verify", nil
+	case mode == TLSVerifyFull && !custom:
+		return "true", nil
+	}
+
+	config, err := d.TLS.config(d.Host)
+	if err != nil {
+		return "", err
+	}
+
+	name := "pkg-" + d.TLS.key(d.Host)
+
+	err = base.RegisterTLSConfig(name, config)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to register tls configuration")
+	}
+
+	return name, nil
+}
@@ -103,6 +103,98 @@ func TestMySQL_GetDialector(t *testing.T) {
 	}
 }
 
+func TestMySQL_GetDialector_TLS(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		options  drivers.TLS
+		expected string
+	}{
+		"disable": {
+			options:  drivers.TLS{Mode: drivers.TLSDisable},
+			expected: "false",
+		},
+		"require": {
+			options:  drivers.TLS{Mode: drivers.TLSRequire},
+			expected: "skip-verify",
+		},
+		"verify-full": {
+			options:  drivers.TLS{Mode: drivers.TLSVerifyFull},
+			expected: "true",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			options := &drivers.MySQL{Name: "test", TLS: testcase.options}
+
+			dialector, err := options.GetDialector()
+			require.NoError(t, err)
+
+			actual, ok := dialector.(mysql.Dialector)
+			require.True(t, ok)
+			assert.Equal(t, "@/test?charset=utf8mb4&parseTime=True&loc=UTC&tls="+testcase.expected, actual.DSN)
+		})
+	}
+}
+
+func TestMySQL_GetDialector_TLSCustom(t *testing.T) {
+	t.Parallel()
+
+	options := &drivers.MySQL{
+		Host: "mysql.host",
+		Name: "test",
+		TLS:  drivers.TLS{Mode: drivers.TLSVerifyFull, ServerName: "db.internal"},
+	}
+
+	dialector, err := options.GetDialector()
+	require.NoError(t, err)
+
+	actual, ok := dialector.(mysql.Dialector)
+	require.True(t, ok)
+	assert.Regexp(t, `^@tcp\(mysql.host\)/test\?charset=utf8mb4&parseTime=True&loc=UTC&tls=pkg-[0-9a-f]{16}$`, actual.DSN)
+	require.NotNil(t, actual.DSNConfig)
+	assert.Equal(t, "db.internal", actual.DSNConfig.TLS.ServerName)
+}
+
+func TestMySQL_GetDialector_ErrInvalidTLS(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		options  drivers.TLS
+		expected string
+	}{
+		"invalid mode": {
+			options:  drivers.TLS{Mode: "invalid"},
+			expected: "unsupported tls mode: invalid",
+		},
+		"missing ca": {
+			options:  drivers.TLS{CA: "/nonexistent/ca.pem", Mode: drivers.TLSVerifyCA},
+			expected: "unable to read certificate authority: open /nonexistent/ca.pem: no such file or directory",
+		},
+		"missing ca without mode": {
+			options:  drivers.TLS{CA: "/nonexistent/ca.pem"},
+			expected: "unable to read certificate authority: open /nonexistent/ca.pem: no such file or directory",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			options := &drivers.MySQL{Name: "test", TLS: testcase.options}
+
+			dialector, err := options.GetDialector()
+			require.Error(t, err)
+
+			require.EqualError(t, err, testcase.expected)
+			assert.Nil(t, dialector)
+		})
+	}
+}
+
 func TestMySQL_GetDialector_ErrInvalidDatabase(t *testing.T) {
 	t.Parallel()
 
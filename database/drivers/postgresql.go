@@ -1,9 +1,12 @@
 package drivers
 
 import (
+	"database/sql"
 	"fmt"
 	"strings"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
@@ -12,12 +15,12 @@ import (
 
 // PostgreSQL options available when connecting to this driver.
 type PostgreSQL struct {
-	Host            string
-	Name            string
-	Password        string
-	Port            int
-	SSLModeDisabled bool
-	Username        string
+	Host     string
+	Name     string
+	Password string
+	Port     int
+	TLS      TLS
+	Username string
 }
 
 // GetDialector gorm dialector for this driver.
@@ -41,22 +44,67 @@ func (d PostgreSQL) GetDialector() (gorm.Dialector, error) {
 		dsn = append(dsn, fmt.Sprintf("port=%d", d.Port))
 	}
 
-	if d.SSLModeDisabled {
-		dsn = append(dsn, "sslmode=disabled")
+	if d.TLS.Certificate != "" {
+		dsn = append(dsn, "sslcert="+d.TLS.Certificate)
+	}
+
+	if d.TLS.Key != "" {
+		dsn = append(dsn, "sslkey="+d.TLS.Key)
+	}
+
+	mode, err := d.TLS.mode()
+	if err != nil {
+		return nil, err
+	}
+
+	if mode != "" {
+		dsn = append(dsn, "sslmode="+string(mode))
+	}
+
+	if d.TLS.CA != "" {
+		dsn = append(dsn, "sslrootcert="+d.TLS.CA)
 	}
 
 	if d.Username != "" {
 		dsn = append(dsn, "user="+d.Username)
 	}
 
-	return postgres.Dialector{
-		Config: &postgres.Config{
-			DriverName:           "",
-			DSN:                  strings.Join(dsn, " "),
-			WithoutQuotingCheck:  false,
-			PreferSimpleProtocol: false,
-			WithoutReturning:     false,
-			Conn:                 nil,
-		},
-	}, nil
+	config := &postgres.Config{
+		DriverName:           "",
+		DSN:                  strings.Join(dsn, " "),
+		WithoutQuotingCheck:  false,
+		PreferSimpleProtocol: false,
+		WithoutReturning:     false,
+		Conn:                 nil,
+	}
+
+	// pgx has no dsn parameter to override the server name, so the connection must be created manually
+	if d.TLS.ServerName != "" {
+		config.Conn, err = d.connection(config.DSN)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return postgres.Dialector{Config: config}, nil
+}
+
+// connection create a connection which verifies the server certificate against a custom server name.
+func (d PostgreSQL) connection(dsn string) (*sql.DB, error) {
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse connection configuration")
+	}
+
+	if config.TLSConfig != nil {
+		config.TLSConfig.ServerName = d.TLS.ServerName
+	}
+
+	for _, fallback := range config.Fallbacks {
+		if fallback.TLSConfig != nil {
+			fallback.TLSConfig.ServerName = d.TLS.ServerName
+		}
+	}
+
+	return stdlib.OpenDB(*config), nil
 }
@@ -19,8 +19,7 @@ func TestPostgreSQL_GetDialector(t *testing.T) {
 	}{
 		"name only": {
 			options: &drivers.PostgreSQL{
-				Name:            "test",
-				SSLModeDisabled: false,
+				Name: "test",
 			},
 			expected: "dbname=test",
 		},
@@ -62,23 +61,49 @@ func TestPostgreSQL_GetDialector(t *testing.T) {
 			},
 			expected: "dbname=test password=password user=root",
 		},
+		"sslmode disable": {
+			options: &drivers.PostgreSQL{
+				Name: "test",
+				TLS:  drivers.TLS{Mode: drivers.TLSDisable},
+			},
+			expected: "dbname=test sslmode=disable",
+		},
 		"sslmode disabled": {
 			options: &drivers.PostgreSQL{
-				Name:            "test",
-				SSLModeDisabled: true,
+				Name: "test",
+				TLS:  drivers.TLS{Mode: "disabled"},
+			},
+			expected: "dbname=test sslmode=disable",
+		},
+		"tls": {
+			options: &drivers.PostgreSQL{
+				Name: "test",
+				TLS: drivers.TLS{
+					CA:          "/ca.pem",
+					Certificate: "/client.pem",
+					Key:         "/client.key",
+					Mode:        drivers.TLSVerifyFull,
+				},
+			},
+			expected: "dbname=test sslcert=/client.pem sslkey=/client.key sslmode=verify-full sslrootcert=/ca.pem",
+		},
+		"tls without mode": {
+			options: &drivers.PostgreSQL{
+				Name: "test",
+				TLS:  drivers.TLS{CA: "/ca.pem"},
 			},
-			expected: "dbname=test sslmode=disabled",
+			expected: "dbname=test sslmode=verify-full sslrootcert=/ca.pem",
 		},
 		"full": {
 			options: &drivers.PostgreSQL{
-				Host:            "postgres.host",
-				Name:            "test",
-				Password:        "password",
-				Port:            5432,
-				SSLModeDisabled: true,
-				Username:        "root",
+				Host:     "postgres.host",
+				Name:     "test",
+				Password: "password",
+				Port:     5432,
+				TLS:      drivers.TLS{Mode: drivers.TLSRequire},
+				Username: "root",
 			},
-			expected: "dbname=test host=postgres.host password=password port=5432 sslmode=disabled user=root",
+			expected: "dbname=test host=postgres.host password=password port=5432 sslmode=require user=root",
 		},
 	}
 
@@ -92,10 +117,43 @@ func TestPostgreSQL_GetDialector(t *testing.T) {
 			actual, ok := dialector.(postgres.Dialector)
 			require.True(t, ok)
 			assert.Equal(t, "TimeZone=UTC "+testcase.expected, actual.DSN)
+			assert.Nil(t, actual.Conn)
 		})
 	}
 }
 
+func TestPostgreSQL_GetDialector_ServerName(t *testing.T) {
+	t.Parallel()
+
+	options := &drivers.PostgreSQL{
+		Host: "postgres.host",
+		Name: "test",
+		TLS:  drivers.TLS{Mode: drivers.TLSRequire, ServerName: "db.internal"},
+	}
+
+	dialector, err := options.GetDialector()
+	require.NoError(t, err)
+
+	actual, ok := dialector.(postgres.Dialector)
+	require.True(t, ok)
+	assert.NotNil(t, actual.Conn)
+}
+
+func TestPostgreSQL_GetDialector_ErrInvalidTLS(t *testing.T) {
+	t.Parallel()
+
+	options := &drivers.PostgreSQL{
+		Name: "test",
+		TLS:  drivers.TLS{Mode: "invalid"},
+	}
+
+	dialector, err := options.GetDialector()
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unsupported tls mode: invalid")
+	assert.Nil(t, dialector)
+}
+
 func TestPostgreSQL_GetDialector_ErrInvalidDatabase(t *testing.T) {
 	t.Parallel()
 
@@ -1,8 +1,11 @@
 package drivers
 
 import (
+	"database/sql"
 	"fmt"
 
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/microsoft/go-mssqldb/msdsn"
 	"gorm.io/driver/sqlserver"
 	"gorm.io/gorm"
 
@@ -15,6 +18,7 @@ type SQLServer struct {
 	Name     string
 	Password string
 	Port     int
+	TLS      TLS
 	Username string
 }
 
@@ -42,12 +46,61 @@ func (d SQLServer) GetDialector() (gorm.Dialector, error) {
 		d.Name,
 	)
 
-	return sqlserver.Dialector{
-		Config: &sqlserver.Config{
-			DriverName:        "",
-			DSN:               dsn,
-			DefaultStringSize: 0,
-			Conn:              nil,
-		},
-	}, nil
+	mode, err := d.TLS.mode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case TLSDisable:
+		dsn += "&encrypt=disable"
+	case TLSRequire, TLSVerifyCA:
+		dsn += "&encrypt=true&trustservercertificate=true"
+	case TLSVerifyFull:
+		dsn += "&encrypt=true&trustservercertificate=false"
+
+		if d.TLS.CA != "" {
+			dsn += "&certificate=" + d.TLS.CA
+		}
+
+		if d.TLS.ServerName != "" {
+			dsn += "&hostnameincertificate=" + d.TLS.ServerName
+		}
+	}
+
+	config := &sqlserver.Config{
+		DriverName:        "",
+		DSN:               dsn,
+		DefaultStringSize: 0,
+		Conn:              nil,
+	}
+
+	// Client certificates and verifying only the certificate authority can't be expressed in the dsn
+	custom := d.TLS.clientCertificate() || mode == TLSVerifyCA || (mode == TLSRequire && d.TLS.CA != "")
+	if custom && mode != "" && mode != TLSDisable {
+		config.Conn, err = d.connection(dsn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sqlserver.Dialector{Config: config}, nil
+}
+
+// connection create a connection using a custom tls configuration.
+func (d SQLServer) connection(dsn string) (*sql.DB, error) {
+	config, err := msdsn.Parse(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse connection configuration")
+	}
+
+	config.TLSConfig, err = d.TLS.config(d.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQL Server expects one tcp segment per encrypted packet, refer https://github.com/microsoft/go-mssqldb/issues/166
+	config.TLSConfig.DynamicRecordSizingDisabled = true
+
+	return sql.OpenDB(mssql.NewConnectorConfig(config)), nil
 }
@@ -87,6 +87,90 @@ func TestSQLServer_GetDialector(t *testing.T) {
 	}
 }
 
+func TestSQLServer_GetDialector_TLS(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		connection bool
+		expected   string
+		options    drivers.TLS
+	}{
+		"disable": {
+			expected: "&encrypt=disable",
+			options:  drivers.TLS{Mode: drivers.TLSDisable},
+		},
+		"require": {
+			expected: "&encrypt=true&trustservercertificate=true",
+			options:  drivers.TLS{Mode: drivers.TLSRequire},
+		},
+		"verify-ca": {
+			connection: true,
+			expected:   "&encrypt=true&trustservercertificate=true",
+			options:    drivers.TLS{Mode: drivers.TLSVerifyCA},
+		},
+		"verify-full": {
+			expected: "&encrypt=true&trustservercertificate=false&certificate=/ca.pem&hostnameincertificate=db.internal",
+			options:  drivers.TLS{CA: "/ca.pem", Mode: drivers.TLSVerifyFull, ServerName: "db.internal"},
+		},
+		"verify-full implied": {
+			expected: "&encrypt=true&trustservercertificate=false&certificate=/ca.pem&hostnameincertificate=db.internal",
+			options:  drivers.TLS{CA: "/ca.pem", ServerName: "db.internal"},
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			options := &drivers.SQLServer{Host: "sqlserver.host", Name: "test", TLS: testcase.options}
+
+			dialector, err := options.GetDialector()
+			require.NoError(t, err)
+
+			actual, ok := dialector.(sqlserver.Dialector)
+			require.True(t, ok)
+			assert.Equal(t, "sqlserver://@sqlserver.host?database=test"+testcase.expected, actual.DSN)
+			assert.Equal(t, testcase.connection, actual.Conn != nil)
+		})
+	}
+}
+
+func TestSQLServer_GetDialector_ErrInvalidTLS(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		options  drivers.TLS
+		expected string
+	}{
+		"invalid mode": {
+			options:  drivers.TLS{Mode: "invalid"},
+			expected: "unsupported tls mode: invalid",
+		},
+		"missing client key": {
+			options:  drivers.TLS{Certificate: "/client.pem", Mode: drivers.TLSRequire},
+			expected: "unable to load client certificate: open /client.pem: no such file or directory",
+		},
+		"missing client key without mode": {
+			options:  drivers.TLS{Certificate: "/client.pem"},
+			expected: "unable to load client certificate: open /client.pem: no such file or directory",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			options := &drivers.SQLServer{Name: "test", TLS: testcase.options}
+
+			dialector, err := options.GetDialector()
+			require.Error(t, err)
+
+			require.EqualError(t, err, testcase.expected)
+			assert.Nil(t, dialector)
+		})
+	}
+}
+
 func TestSQLServer_GetDialector_ErrInvalidDatabase(t *testing.T) {
 	t.Parallel()
 
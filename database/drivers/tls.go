@@ -0,0 +1,167 @@
+package drivers
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/carlmjohnson/truthy"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// TLS options available when connecting to a database over tls.
+type TLS struct {
+	// CA path to a pem encoded certificate authority bundle used to verify the server.
+	CA string
+	// Certificate path to a pem encoded client certificate.
+	Certificate string
+	// Key path to the pem encoded private key for Certificate.
+	Key string
+	// Mode level of verification to perform, empty uses the driver default unless CA, Certificate, Key or ServerName
+	// are provided in which case verify-full is used.
+	Mode TLSMode
+	// ServerName name to verify the server certificate against if it differs from the host.
+	ServerName string
+}
+
+// TLSMode level of verification to perform when connecting over tls.
+type TLSMode string
+
+const (
+	// TLSDisable connect without tls.
+	TLSDisable TLSMode = "disable"
+
+	// TLSRequire connect with tls without verifying the server certificate.
+	TLSRequire TLSMode = "require"
+
+	// TLSVerifyCA connect with tls and verify the server certificate was signed by a trusted certificate authority.
+	TLSVerifyCA TLSMode = "verify-ca"
+
+	// TLSVerifyFull connect with tls and verify both the certificate authority and the server name.
+	TLSVerifyFull TLSMode = "verify-full"
+)
+
+// clientCertificate determine whether a client certificate has been provided.
+func (t TLS) clientCertificate() bool {
+	return t.Certificate != "" || t.Key != ""
+}
+
+// config build a tls configuration for host from options, a nil configuration means tls is disabled.
+func (t TLS) config(host string) (*tls.Config, error) {
+	mode, err := t.mode()
+	if err != nil {
+		return nil, err
+	}
+
+	if mode == "" || mode == TLSDisable {
+		return nil, nil //nolint:nilnil // A nil configuration is valid and means tls is disabled
+	}
+
+	config := new(tls.Config)
+	config.MinVersion = tls.VersionTLS12
+	config.ServerName = truthy.Cond(t.ServerName != "", t.ServerName, host)
+
+	if t.CA != "" {
+		contents, err := os.ReadFile(t.CA)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read certificate authority")
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(contents) {
+			return nil, errors.New("no certificates found in certificate authority: %s", t.CA)
+		}
+	}
+
+	if t.clientCertificate() {
+		certificate, err := tls.LoadX509KeyPair(t.Certificate, t.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load client certificate")
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	// Follow libpq semantics, require will verify the certificate authority if one is provided
+	if mode == TLSVerifyFull {
+		return config, nil
+	}
+
+	config.InsecureSkipVerify = true
+
+	if mode == TLSVerifyCA || config.RootCAs != nil {
+		config.VerifyPeerCertificate = verifyCertificateAuthority(config.RootCAs)
+	}
+
+	return config, nil
+}
+
+// key create a stable key which represents the options for host.
+func (t TLS) key(host string) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%s|%s|%s", host, t.CA, t.Certificate, t.Key, t.Mode, t.ServerName)))
+
+	return hex.EncodeToString(hash[:8])
+}
+
+// mode normalise and validate the requested mode, providing tls options without a mode implies verify-full so the
+// options aren't ignored by connecting without tls.
+func (t TLS) mode() (TLSMode, error) {
+	mode := TLSMode(strings.ToLower(string(t.Mode)))
+
+	switch mode {
+	case "":
+		if t.CA != "" || t.clientCertificate() || t.ServerName != "" {
+			return TLSVerifyFull, nil
+		}
+
+		return "", nil
+	case "disabled":
+		return TLSDisable, nil
+	case TLSDisable, TLSRequire, TLSVerifyCA, TLSVerifyFull:
+		return mode, nil
+	default:
+		return "", errors.New("unsupported tls mode: %s", t.Mode)
+	}
+}
+
+// verifyCertificateAuthority verify the certificate chain presented by a server without verifying the server name.
+func verifyCertificateAuthority(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(certificates [][]byte, _ [][]*x509.Certificate) error {
+		if len(certificates) == 0 {
+			return errors.New("server did not present a certificate")
+		}
+
+		chain := make([]*x509.Certificate, 0, len(certificates))
+
+		for _, raw := range certificates {
+			certificate, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return errors.Wrap(err, "unable to parse server certificate")
+			}
+
+			chain = append(chain, certificate)
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, certificate := range chain[1:] {
+			intermediates.AddCert(certificate)
+		}
+
+		var options x509.VerifyOptions
+
+		options.Intermediates = intermediates
+		options.Roots = roots
+
+		_, err := chain[0].Verify(options)
+		if err != nil {
+			return errors.Wrap(err, "unable to verify server certificate")
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,242 @@
+package drivers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// certificates files and raw certificates created for testing.
+type certificates struct {
+	ca          string
+	certificate string
+	key         string
+	other       []byte
+	server      []byte
+}
+
+func TestTLS_config(t *testing.T) {
+	t.Parallel()
+
+	files := createCertificates(t)
+
+	testcases := map[string]struct {
+		options        TLS
+		insecure       bool
+		nilConfig      bool
+		verifyCallback bool
+	}{
+		"empty": {
+			options:   TLS{},
+			nilConfig: true,
+		},
+		"implied verify-full": {
+			options: TLS{CA: files.ca},
+		},
+		"disable": {
+			options:   TLS{Mode: TLSDisable},
+			nilConfig: true,
+		},
+		"require": {
+			options:  TLS{Mode: TLSRequire},
+			insecure: true,
+		},
+		"require with ca": {
+			options:        TLS{CA: files.ca, Mode: TLSRequire},
+			insecure:       true,
+			verifyCallback: true,
+		},
+		"verify-ca": {
+			options:        TLS{CA: files.ca, Mode: TLSVerifyCA},
+			insecure:       true,
+			verifyCallback: true,
+		},
+		"verify-full": {
+			options: TLS{CA: files.ca, Certificate: files.certificate, Key: files.key, Mode: TLSVerifyFull},
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := testcase.options.config("database.host")
+			require.NoError(t, err)
+
+			if testcase.nilConfig {
+				assert.Nil(t, config)
+
+				return
+			}
+
+			assert.Equal(t, "database.host", config.ServerName)
+			assert.Equal(t, testcase.insecure, config.InsecureSkipVerify)
+			assert.Equal(t, testcase.verifyCallback, config.VerifyPeerCertificate != nil)
+		})
+	}
+}
+
+func TestTLS_config_ClientCertificate(t *testing.T) {
+	t.Parallel()
+
+	files := createCertificates(t)
+
+	options := TLS{Certificate: files.certificate, Key: files.key, Mode: TLSVerifyFull, ServerName: "db.internal"}
+
+	config, err := options.config("database.host")
+	require.NoError(t, err)
+
+	assert.Len(t, config.Certificates, 1)
+	assert.Equal(t, "db.internal", config.ServerName)
+	assert.Nil(t, config.RootCAs)
+}
+
+func TestTLS_config_Errors(t *testing.T) {
+	t.Parallel()
+
+	files := createCertificates(t)
+
+	testcases := map[string]struct {
+		options  TLS
+		expected string
+	}{
+		"invalid ca": {
+			options:  TLS{CA: files.key, Mode: TLSVerifyFull},
+			expected: "no certificates found in certificate authority: " + files.key,
+		},
+		"invalid mode": {
+			options:  TLS{Mode: "prefer"},
+			expected: "unsupported tls mode: prefer",
+		},
+		"missing ca": {
+			options:  TLS{CA: "/nonexistent/ca.pem", Mode: TLSVerifyFull},
+			expected: "unable to read certificate authority: open /nonexistent/ca.pem: no such file or directory",
+		},
+		"missing key": {
+			options:  TLS{Certificate: files.certificate, Mode: TLSVerifyFull},
+			expected: "unable to load client certificate: open : no such file or directory",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config, err := testcase.options.config("database.host")
+			require.Error(t, err)
+
+			require.EqualError(t, err, testcase.expected)
+			assert.Nil(t, config)
+		})
+	}
+}
+
+func TestTLS_key(t *testing.T) {
+	t.Parallel()
+
+	options := TLS{Mode: TLSRequire}
+
+	assert.Equal(t, options.key("host"), options.key("host"))
+	assert.NotEqual(t, options.key("host"), options.key("other"))
+	assert.Len(t, options.key("host"), 16)
+}
+
+func TestVerifyCertificateAuthority(t *testing.T) {
+	t.Parallel()
+
+	files := createCertificates(t)
+
+	contents, err := os.ReadFile(files.ca)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(contents))
+
+	verify := verifyCertificateAuthority(roots)
+
+	require.NoError(t, verify([][]byte{files.server}, nil))
+	require.EqualError(t, verify(nil, nil), "server did not present a certificate")
+	require.ErrorContains(t, verify([][]byte{{0x01}}, nil), "unable to parse server certificate")
+	require.ErrorContains(t, verify([][]byte{files.other}, nil), "unable to verify server certificate")
+}
+
+// createCertificates create a certificate authority, a signed certificate and an unsigned certificate.
+func createCertificates(t *testing.T) certificates {
+	t.Helper()
+
+	directory := t.TempDir()
+
+	authorityKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	authority := &x509.Certificate{
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		NotAfter:              time.Now().Add(time.Hour),
+		NotBefore:             time.Now().Add(-time.Hour),
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "authority"},
+	}
+
+	authorityRaw, err := x509.CreateCertificate(rand.Reader, authority, authority, &authorityKey.PublicKey, authorityKey)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	leaf := &x509.Certificate{
+		DNSNames:     []string{"unrelated.host"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		NotAfter:     time.Now().Add(time.Hour),
+		NotBefore:    time.Now().Add(-time.Hour),
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "leaf"},
+	}
+
+	signed, err := x509.CreateCertificate(rand.Reader, leaf, authority, &key.PublicKey, authorityKey)
+	require.NoError(t, err)
+
+	unsigned, err := x509.CreateCertificate(rand.Reader, leaf, leaf, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyRaw, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	files := certificates{
+		ca:          directory + "/ca.pem",
+		certificate: directory + "/client.pem",
+		key:         directory + "/client.key",
+		other:       unsigned,
+		server:      signed,
+	}
+
+	writePEM(t, files.ca, "CERTIFICATE", authorityRaw)
+	writePEM(t, files.certificate, "CERTIFICATE", signed)
+	writePEM(t, files.key, "EC PRIVATE KEY", keyRaw)
+
+	// Ensure the pair is loadable
+	_, err = tls.LoadX509KeyPair(files.certificate, files.key)
+	require.NoError(t, err)
+
+	return files
+}
+
+// writePEM write pem encoded contents to filename.
+func writePEM(t *testing.T, filename string, block string, contents []byte) {
+	t.Helper()
+
+	err := os.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: block, Bytes: contents}), 0o600)
+	require.NoError(t, err)
+}
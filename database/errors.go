@@ -0,0 +1,258 @@
+package database
+
+import (
+	"database/sql/driver"
+	"net"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	mssql "github.com/microsoft/go-mssqldb"
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// DriverError error returned by a database driver which has been recognised as a typed error.
+type DriverError struct {
+	// Constraint name of the constraint which was violated, if known
+	Constraint string
+	kind       error
+	original   error
+}
+
+// errorTranslator gorm plugin which translates driver errors into typed errors.
+type errorTranslator struct{}
+
+// Typed errors returned when a driver error is recognised, use errors.Is to check for them.
+var (
+	ErrCheckViolation       = errors.New("check constraint violation")
+	ErrConnection           = errors.New("unable to communicate with database")
+	ErrDeadlock             = errors.New("deadlock detected")
+	ErrDuplicateKey         = errors.New("duplicate key violates unique constraint")
+	ErrForeignKeyViolation  = errors.New("foreign key constraint violation")
+	ErrSerializationFailure = errors.New("unable to serialize access due to concurrent update")
+)
+
+// SQLite result codes, extended codes carry the primary code in the lowest byte.
+const (
+	sqliteBusy                 = 5
+	sqliteBusySnapshot         = 517
+	sqliteCantOpen             = 14
+	sqliteConstraintCheck      = 275
+	sqliteConstraintForeignKey = 787
+	sqliteConstraintPrimaryKey = 1555
+	sqliteConstraintUnique     = 2067
+	sqliteLocked               = 6
+)
+
+// Error return the original driver error message.
+func (e DriverError) Error() string {
+	return e.original.Error()
+}
+
+// Is determine if the typed error matches target.
+func (e DriverError) Is(target error) bool {
+	return errors.Is(e.kind, target)
+}
+
+// Unwrap return the original driver error.
+func (e DriverError) Unwrap() error {
+	return e.original
+}
+
+// Initialize register callbacks which translate errors after every operation.
+func (t errorTranslator) Initialize(orm *gorm.DB) error {
+	callbacks := orm.Callback()
+
+	registrations := []func() error{
+		func() error { return callbacks.Create().After("*").Register(t.Name(), translateErrors) },
+		func() error { return callbacks.Delete().After("*").Register(t.Name(), translateErrors) },
+		func() error { return callbacks.Query().After("*").Register(t.Name(), translateErrors) },
+		func() error { return callbacks.Raw().After("*").Register(t.Name(), translateErrors) },
+		func() error { return callbacks.Row().After("*").Register(t.Name(), translateErrors) },
+		func() error { return callbacks.Update().After("*").Register(t.Name(), translateErrors) },
+	}
+
+	for _, register := range registrations {
+		err := register()
+		if err != nil {
+			return errors.Wrap(err, "unable to register error translation")
+		}
+	}
+
+	return nil
+}
+
+// Name of the plugin.
+func (t errorTranslator) Name() string {
+	return "database:translate_errors"
+}
+
+// between find the text in message between prefix and suffix, or the end of the message if suffix isn't found.
+func between(message string, prefix string, suffix string) string {
+	_, after, found := strings.Cut(message, prefix)
+	if !found {
+		return ""
+	}
+
+	before, _, _ := strings.Cut(after, suffix)
+
+	return before
+}
+
+// classify determine the typed error and constraint for a driver error.
+func classify(err error) (error, string) {
+	var mysqlError *mysql.MySQLError
+	if errors.As(err, &mysqlError) {
+		return classifyMySQL(mysqlError)
+	}
+
+	var postgresError *pgconn.PgError
+	if errors.As(err, &postgresError) {
+		return classifyPostgreSQL(postgresError)
+	}
+
+	var sqlserverError mssql.Error
+	if errors.As(err, &sqlserverError) {
+		return classifySQLServer(sqlserverError)
+	}
+
+	// The SQLite driver error type is unexported via the gorm driver, so match on behaviour
+	var sqliteError interface {
+		Code() int
+		Error() string
+	}
+	if errors.As(err, &sqliteError) {
+		return classifySQLite(sqliteError.Code(), sqliteError.Error())
+	}
+
+	var connectError *pgconn.ConnectError
+
+	var networkError net.Error
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || errors.As(err, &connectError) ||
+		errors.As(err, &networkError) {
+		return ErrConnection, ""
+	}
+
+	return nil, ""
+}
+
+// classifyMySQL determine the typed error and constraint for a MySQL error.
+func classifyMySQL(err *mysql.MySQLError) (error, string) {
+	switch err.Number {
+	case 1062, 1586:
+		return ErrDuplicateKey, between(err.Message, "for key '", "'")
+	case 1216, 1217, 1451, 1452:
+		return ErrForeignKeyViolation, between(err.Message, "CONSTRAINT `", "`")
+	case 3819:
+		return ErrCheckViolation, between(err.Message, "constraint '", "'")
+	case 1213:
+		return ErrDeadlock, ""
+	case 1040, 1042, 1043, 1047, 1053, 1081, 1129, 1152, 1153, 1158, 1159, 1160, 1161, 2002, 2003, 2006, 2013:
+		return ErrConnection, ""
+	}
+
+	return nil, ""
+}
+
+// classifyPostgreSQL determine the typed error and constraint for a PostgreSQL error.
+func classifyPostgreSQL(err *pgconn.PgError) (error, string) {
+	switch err.Code {
+	case "23505":
+		return ErrDuplicateKey, err.ConstraintName
+	case "23503":
+		return ErrForeignKeyViolation, err.ConstraintName
+	case "23514":
+		return ErrCheckViolation, err.ConstraintName
+	case "40P01":
+		return ErrDeadlock, ""
+	case "40001":
+		return ErrSerializationFailure, ""
+	}
+
+	// Class 08 is connection exceptions, 57P01 to 57P03 are server shutdowns
+	if strings.HasPrefix(err.Code, "08") || err.Code == "57P01" || err.Code == "57P02" || err.Code == "57P03" {
+		return ErrConnection, ""
+	}
+
+	return nil, ""
+}
+
+// classifySQLite determine the typed error and constraint for a SQLite error.
+func classifySQLite(code int, message string) (error, string) {
+	// Constraint messages end with the constraint, e.g. constraint failed: UNIQUE constraint failed: users.email (2067)
+	constraint := ""
+	if index := strings.LastIndex(message, "constraint failed: "); index >= 0 {
+		constraint = between(message[index:], "constraint failed: ", " (")
+	}
+
+	switch code {
+	case sqliteConstraintPrimaryKey, sqliteConstraintUnique:
+		return ErrDuplicateKey, constraint
+	case sqliteConstraintForeignKey:
+		return ErrForeignKeyViolation, ""
+	case sqliteConstraintCheck:
+		return ErrCheckViolation, constraint
+	case sqliteBusySnapshot:
+		return ErrSerializationFailure, ""
+	case sqliteCantOpen:
+		return ErrConnection, ""
+	}
+
+	// SQLite doesn't detect deadlocks, a busy or locked database is the closest equivalent
+	if code&0xff == sqliteBusy || code&0xff == sqliteLocked {
+		return ErrDeadlock, ""
+	}
+
+	return nil, ""
+}
+
+// classifySQLServer determine the typed error and constraint for a SQL Server error.
+func classifySQLServer(err mssql.Error) (error, string) {
+	switch err.Number {
+	case 2601:
+		return ErrDuplicateKey, between(err.Message, "unique index '", "'")
+	case 2627:
+		return ErrDuplicateKey, between(err.Message, "constraint '", "'")
+	case 547:
+		constraint := between(err.Message, "constraint \"", "\"")
+
+		if strings.Contains(err.Message, "CHECK constraint") {
+			return ErrCheckViolation, constraint
+		}
+
+		return ErrForeignKeyViolation, constraint
+	case 1205:
+		return ErrDeadlock, ""
+	case 3960:
+		return ErrSerializationFailure, ""
+	}
+
+	return nil, ""
+}
+
+// translate a driver error into a typed error, unrecognised errors are returned as is.
+func translate(err error) error {
+	var driverError DriverError
+	if err == nil || errors.As(err, &driverError) {
+		return err
+	}
+
+	kind, constraint := classify(err)
+	if kind == nil {
+		return err
+	}
+
+	return DriverError{
+		Constraint: constraint,
+		kind:       kind,
+		original:   err,
+	}
+}
+
+// translateErrors callback which translates the error from an operation.
+func translateErrors(orm *gorm.DB) {
+	orm.Error = translate(orm.Error)
+}
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	mssql "github.com/microsoft/go-mssqldb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// sqliteError fakes the SQLite driver error which can't be created outside the driver.
+type sqliteError struct {
+	code    int
+	message string
+}
+
+// Code return the SQLite result code.
+func (e sqliteError) Code() int {
+	return e.code
+}
+
+// Error return the error message.
+func (e sqliteError) Error() string {
+	return e.message
+}
+
+func TestTranslate(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		err        error
+		constraint string
+		expected   error
+	}{
+		"mysql check": {
+			err:        &mysql.MySQLError{Number: 3819, Message: "Check constraint 'positive_amount' is violated."},
+			constraint: "positive_amount",
+			expected:   ErrCheckViolation,
+		},
+		"mysql connection": {
+			err:      &mysql.MySQLError{Number: 2013, Message: "Lost connection to MySQL server during query"},
+			expected: ErrConnection,
+		},
+		"mysql deadlock": {
+			err:      &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"},
+			expected: ErrDeadlock,
+		},
+		"mysql duplicate": {
+			err:        &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@b.c' for key 'users.email'"},
+			constraint: "users.email",
+			expected:   ErrDuplicateKey,
+		},
+		"mysql foreign key": {
+			err: &mysql.MySQLError{
+				Number:  1452,
+				Message: "Cannot add or update a child row: a foreign key constraint fails (`db`.`posts`, CONSTRAINT `fk_posts_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))",
+			},
+			constraint: "fk_posts_user",
+			expected:   ErrForeignKeyViolation,
+		},
+		"mysql invalid connection": {
+			err:      mysql.ErrInvalidConn,
+			expected: ErrConnection,
+		},
+		"postgres check": {
+			err:        &pgconn.PgError{Code: "23514", ConstraintName: "positive_amount"},
+			constraint: "positive_amount",
+			expected:   ErrCheckViolation,
+		},
+		"postgres connection": {
+			err:      &pgconn.PgError{Code: "08006"},
+			expected: ErrConnection,
+		},
+		"postgres deadlock": {
+			err:      &pgconn.PgError{Code: "40P01"},
+			expected: ErrDeadlock,
+		},
+		"postgres duplicate": {
+			err:        &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"},
+			constraint: "users_email_key",
+			expected:   ErrDuplicateKey,
+		},
+		"postgres foreign key": {
+			err:        &pgconn.PgError{Code: "23503", ConstraintName: "fk_posts_user"},
+			constraint: "fk_posts_user",
+			expected:   ErrForeignKeyViolation,
+		},
+		"postgres serialization": {
+			err:      &pgconn.PgError{Code: "40001"},
+			expected: ErrSerializationFailure,
+		},
+		"sqlite busy": {
+			err:      sqliteError{code: 5, message: "database is locked (5) (SQLITE_BUSY)"},
+			expected: ErrDeadlock,
+		},
+		"sqlite busy snapshot": {
+			err:      sqliteError{code: 517, message: "database is locked (517)"},
+			expected: ErrSerializationFailure,
+		},
+		"sqlite cant open": {
+			err:      sqliteError{code: 14, message: "unable to open database file (14)"},
+			expected: ErrConnection,
+		},
+		"sqlite unique": {
+			err:        sqliteError{code: 2067, message: "constraint failed: UNIQUE constraint failed: users.email (2067)"},
+			constraint: "users.email",
+			expected:   ErrDuplicateKey,
+		},
+		"sqlserver check": {
+			err: mssql.Error{
+				Number:  547,
+				Message: `The INSERT statement conflicted with the CHECK constraint "CK_amount". The conflict occurred in database "db", table "dbo.orders", column 'amount'.`,
+			},
+			constraint: "CK_amount",
+			expected:   ErrCheckViolation,
+		},
+		"sqlserver deadlock": {
+			err:      mssql.Error{Number: 1205, Message: "Transaction was deadlocked on lock resources with another process"},
+			expected: ErrDeadlock,
+		},
+		"sqlserver duplicate constraint": {
+			err: mssql.Error{
+				Number:  2627,
+				Message: "Violation of UNIQUE KEY constraint 'UQ_users_email'. Cannot insert duplicate key in object 'dbo.users'.",
+			},
+			constraint: "UQ_users_email",
+			expected:   ErrDuplicateKey,
+		},
+		"sqlserver duplicate index": {
+			err: mssql.Error{
+				Number:  2601,
+				Message: "Cannot insert duplicate key row in object 'dbo.users' with unique index 'IX_users_email'.",
+			},
+			constraint: "IX_users_email",
+			expected:   ErrDuplicateKey,
+		},
+		"sqlserver foreign key": {
+			err: mssql.Error{
+				Number:  547,
+				Message: `The DELETE statement conflicted with the REFERENCE constraint "FK_posts_user". The conflict occurred in database "db", table "dbo.posts", column 'user_id'.`,
+			},
+			constraint: "FK_posts_user",
+			expected:   ErrForeignKeyViolation,
+		},
+		"sqlserver serialization": {
+			err:      mssql.Error{Number: 3960, Message: "Snapshot isolation transaction aborted due to update conflict."},
+			expected: ErrSerializationFailure,
+		},
+		"wrapped bad connection": {
+			err:      errors.Wrap(driver.ErrBadConn, "test"),
+			expected: ErrConnection,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := translate(testcase.err)
+			require.Error(t, err)
+
+			var driverError DriverError
+
+			require.ErrorIs(t, err, testcase.expected)
+			require.ErrorAs(t, err, &driverError)
+			assert.Equal(t, testcase.err, driverError.Unwrap())
+			assert.Equal(t, testcase.constraint, driverError.Constraint)
+			assert.Equal(t, testcase.err.Error(), err.Error())
+			assert.Equal(t, err, translate(err))
+		})
+	}
+}
+
+func TestTranslate_Unrecognised(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		err error
+	}{
+		"generic": {
+			err: errors.New("test"),
+		},
+		"mysql": {
+			err: &mysql.MySQLError{Number: 1146, Message: "Table 'db.missing' doesn't exist"},
+		},
+		"nil": {
+			err: nil,
+		},
+		"postgres": {
+			err: &pgconn.PgError{Code: "42P01"},
+		},
+		"sqlite": {
+			err: sqliteError{code: 1, message: "no such table: missing (1)"},
+		},
+		"sqlserver": {
+			err: mssql.Error{Number: 208, Message: "Invalid object name 'missing'."},
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testcase.err, translate(testcase.err))
+		})
+	}
+}
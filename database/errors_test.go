@@ -0,0 +1,74 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+func TestDriverError_CheckViolation(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+
+	result := connection.ORM().Exec("CREATE TABLE checked (id INTEGER PRIMARY KEY, amount INTEGER CONSTRAINT positive CHECK (amount > 0))")
+	require.NoError(t, result.Error)
+
+	err := connection.ORM().Exec("INSERT INTO checked (amount) VALUES (?)", -1).Error
+	require.Error(t, err)
+
+	var driverError database.DriverError
+
+	require.ErrorIs(t, err, database.ErrCheckViolation)
+	require.ErrorAs(t, err, &driverError)
+	assert.Equal(t, "positive", driverError.Constraint)
+}
+
+func TestDriverError_DuplicateKey(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 1))
+
+	err := instance.Create(&modelmock.ModelMock{ID: 1})
+	require.Error(t, err)
+
+	var driverError database.DriverError
+
+	require.ErrorIs(t, err, database.ErrDuplicateKey)
+	require.ErrorAs(t, err, &driverError)
+	assert.Equal(t, "model_mocks.id", driverError.Constraint)
+	assert.False(t, errors.Is(err, database.ErrForeignKeyViolation))
+	assert.Contains(t, err.Error(), "unable to create record: ")
+}
+
+func TestDriverError_ForeignKeyViolation(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+
+	result := connection.ORM().Exec("CREATE TABLE children (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES model_mocks(id))")
+	require.NoError(t, result.Error)
+
+	err := connection.ORM().Exec("INSERT INTO children (parent_id) VALUES (?)", 10).Error
+	require.Error(t, err)
+
+	require.ErrorIs(t, err, database.ErrForeignKeyViolation)
+}
+
+func TestDriverError_Transaction(t *testing.T) {
+	t.Parallel()
+
+	connection := seed(t, 1)
+
+	err := connection.WithTransaction(t.Context(), func(tx database.Connection) error {
+		return database.Repository[modelmock.ModelMock](tx).Create(&modelmock.ModelMock{ID: 1})
+	})
+	require.Error(t, err)
+
+	require.ErrorIs(t, err, database.ErrDuplicateKey)
+}
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"reflect"
+
+	"gorm.io/gorm/clause"
+)
+
+// Filter typed where condition, columns are validated against the model schema when the query is built.
+type Filter interface {
+	build(compiler compiler) (clause.Expression, error)
+}
+
+// compiler resolves columns and conditions while building filters.
+type compiler interface {
+	column(name string) (clause.Column, error)
+	condition(where any) (clause.Expression, error)
+}
+
+// comparison filter on a single column.
+type comparison struct {
+	column     string
+	expression func(column clause.Column) clause.Expression
+}
+
+// group filter combining other filters.
+type group struct {
+	combine func(expressions ...clause.Expression) clause.Expression
+	filters []Filter
+}
+
+// And match records which match every filter.
+func And(filters ...Filter) Filter {
+	return group{combine: clause.And, filters: filters}
+}
+
+// Between match records where column is between lower and upper inclusive.
+func Between(column string, lower any, upper any) Filter {
+	return comparison{column: column, expression: func(column clause.Column) clause.Expression {
+		return clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []any{column, lower, upper}, WithoutParentheses: false}
+	}}
+}
+
+// Eq match records where column equals value, a nil value matches null.
+func Eq(column string, value any) Filter {
+	return comparison{column: column, expression: func(column clause.Column) clause.Expression {
+		return clause.Eq{Column: column, Value: value}
+	}}
+}
+
+// Gt match records where column is greater than value.
+func Gt(column string, value any) Filter {
+	return comparison{column: column, expression: func(column clause.Column) clause.Expression {
+		return clause.Gt{Column: column, Value: value}
+	}}
+}
+
+// Gte match records where column is greater than or equal to value.
+func Gte(column string, value any) Filter {
+	return comparison{column: column, expression: func(column clause.Column) clause.Expression {
+		return clause.Gte{Column: column, Value: value}
+	}}
+}
+
+// In match records where column is one of values, a single slice is expanded into values.
+func In(column string, values ...any) Filter {
+	if len(values) == 1 {
+		values = expand(values[0])
+	}
+
+	return comparison{column: column, expression: func(column clause.Column) clause.Expression {
+		return clause.IN{Column: column, Values: values}
+	}}
+}
+
+// IsNull match records where column is null.
+func IsNull(column string) Filter {
+	return Eq(column, nil)
+}
+
+// Like match records where column matches pattern.
+func Like(column string, pattern string) Filter {
+	return comparison{column: column, expression: func(column clause.Column) clause.Expression {
+		return clause.Like{Column: column, Value: pattern}
+	}}
+}
+
+// Lt match records where column is less than value.
+func Lt(column string, value any) Filter {
+	return comparison{column: column, expression: func(column clause.Column) clause.Expression {
+		return clause.Lt{Column: column, Value: value}
+	}}
+}
+
+// Lte match records where column is less than or equal to value.
+func Lte(column string, value any) Filter {
+	return comparison{column: column, expression: func(column clause.Column) clause.Expression {
+		return clause.Lte{Column: column, Value: value}
+	}}
+}
+
+// Ne match records where column does not equal value, a nil value matches not null.
+func Ne(column string, value any) Filter {
+	return comparison{column: column, expression: func(column clause.Column) clause.Expression {
+		return clause.Neq{Column: column, Value: value}
+	}}
+}
+
+// Not match records which don't match every filter.
+func Not(filters ...Filter) Filter {
+	return group{combine: not, filters: filters}
+}
+
+// NotIn match records where column is not one of values.
+func NotIn(column string, values ...any) Filter {
+	return Not(In(column, values...))
+}
+
+// expand a slice into values, anything else is returned as a single value.
+func expand(value any) []any {
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() != reflect.Slice || reflected.Type().Elem().Kind() == reflect.Uint8 {
+		return []any{value}
+	}
+
+	values := make([]any, reflected.Len())
+	for index := range values {
+		values[index] = reflected.Index(index).Interface()
+	}
+
+	return values
+}
+
+// not negate expressions as a whole, gorm negates multiple expressions individually which isn't the same thing.
+func not(expressions ...clause.Expression) clause.Expression {
+	expression := clause.And(expressions...)
+	if expression == nil {
+		return nil
+	}
+
+	if _, ok := expression.(clause.AndConditions); ok {
+		return clause.Expr{SQL: "NOT (?)", Vars: []any{expression}, WithoutParentheses: false}
+	}
+
+	return clause.Not(expression)
+}
+
+// build resolve the column and create the expression.
+func (c comparison) build(compiler compiler) (clause.Expression, error) {
+	column, err := compiler.column(c.column)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.expression(column), nil
+}
+
+// build each filter and combine the expressions.
+func (g group) build(compiler compiler) (clause.Expression, error) {
+	expressions := make([]clause.Expression, 0, len(g.filters))
+
+	for _, filter := range g.filters {
+		expression, err := filter.build(compiler)
+		if err != nil {
+			return nil, err
+		}
+
+		expressions = append(expressions, expression)
+	}
+
+	return g.combine(expressions...), nil
+}
+
+// build each condition and combine the expressions, conditions may be filters, models, maps or raw queries.
+func (o Or) build(compiler compiler) (clause.Expression, error) {
+	expressions := make([]clause.Expression, 0, len(o))
+
+	for _, where := range o {
+		expression, err := compiler.condition(where)
+		if err != nil {
+			return nil, err
+		}
+
+		if expression != nil {
+			expressions = append(expressions, expression)
+		}
+	}
+
+	return clause.Or(expressions...), nil
+}
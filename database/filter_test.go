@@ -0,0 +1,267 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+// ownerMock model with a has one relationship to join.
+type ownerMock struct {
+	ID   int
+	Name string
+	Pet  petMock `gorm:"foreignKey:OwnerID"`
+}
+
+// petMock has one relationship of an owner.
+type petMock struct {
+	ID      int
+	Name    string
+	OwnerID int
+}
+
+// TableName return the database table for this model.
+func (o ownerMock) TableName() string {
+	return "owner_mocks"
+}
+
+// TableName return the database table for this model.
+func (p petMock) TableName() string {
+	return "pet_mocks"
+}
+
+func TestFilters(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 5))
+
+	testcases := map[string]struct {
+		expected []int
+		where    []any
+	}{
+		"and": {
+			expected: []int{3, 5},
+			where:    []any{database.And(database.Eq("Test", true), database.Gt("ID", 1))},
+		},
+		"between": {
+			expected: []int{2, 3, 4},
+			where:    []any{database.Between("ID", 2, 4)},
+		},
+		"eq": {
+			expected: []int{2},
+			where:    []any{database.Eq("ID", 2)},
+		},
+		"gt": {
+			expected: []int{4, 5},
+			where:    []any{database.Gt("ID", 3)},
+		},
+		"gte": {
+			expected: []int{3, 4, 5},
+			where:    []any{database.Gte("ID", 3)},
+		},
+		"in": {
+			expected: []int{1, 4},
+			where:    []any{database.In("ID", 1, 4)},
+		},
+		"in slice": {
+			expected: []int{2, 5},
+			where:    []any{database.In("ID", []int{2, 5})},
+		},
+		"is null": {
+			expected: []int{1, 2, 3, 4, 5},
+			where:    []any{database.IsNull("DeletedAt")},
+		},
+		"like": {
+			expected: []int{1, 2, 3, 4, 5},
+			where:    []any{database.Like("ID", "%")},
+		},
+		"lt": {
+			expected: []int{1, 2},
+			where:    []any{database.Lt("ID", 3)},
+		},
+		"lte": {
+			expected: []int{1, 2, 3},
+			where:    []any{database.Lte("ID", 3)},
+		},
+		"mixed with models": {
+			expected: []int{3},
+			where:    []any{&modelmock.ModelMock{Test: true}, database.Ne("ID", 1), database.Lt("ID", 5)},
+		},
+		"ne": {
+			expected: []int{1, 2, 4, 5},
+			where:    []any{database.Ne("ID", 3)},
+		},
+		"not": {
+			expected: []int{1, 2, 4, 5},
+			where:    []any{database.Not(database.Eq("ID", 3))},
+		},
+		"not multiple": {
+			expected: []int{1, 2, 4},
+			where:    []any{database.Not(database.Eq("Test", true), database.Gt("ID", 2))},
+		},
+		"not in": {
+			expected: []int{2, 3},
+			where:    []any{database.NotIn("ID", 1, 4, 5)},
+		},
+		"or": {
+			expected: []int{1, 4, 5},
+			where: []any{database.Or{
+				database.Eq("ID", 1),
+				database.And(database.Gt("ID", 3), database.Lte("ID", 5)),
+			}},
+		},
+		"or mixed": {
+			expected: []int{2, 3, 4},
+			where: []any{database.Or{
+				&modelmock.ModelMock{ID: 2},
+				map[string]any{"id": 3},
+				database.Raw{Query: "id = ?", Parameters: []any{4}},
+			}},
+		},
+		"or nested": {
+			expected: []int{2, 5},
+			where: []any{database.And(
+				database.Ne("ID", 3),
+				database.Or{database.Eq("ID", 2), database.Eq("ID", 3), database.Eq("ID", 5)},
+			)},
+		},
+		"table prefixed column": {
+			expected: []int{1},
+			where:    []any{database.Eq("model_mocks.id", 1)},
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			models, err := instance.Get(testcase.where...)
+			require.NoError(t, err)
+
+			assert.Equal(t, testcase.expected, ids(models))
+		})
+	}
+}
+
+func TestFilters_Aggregates(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 5))
+
+	count, err := instance.Count(database.In("ID", 1, 2, 3))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), count)
+
+	deleted, err := instance.DeleteWhere(database.Gt("ID", 4))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), deleted)
+}
+
+func TestFilters_PrefixedColumns(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+	require.NoError(t, connection.Migrate(ownerMock{}, petMock{}))
+
+	for _, owner := range []*ownerMock{{Name: "ann", Pet: petMock{Name: "rex"}}, {Name: "bob", Pet: petMock{Name: "tom"}}} {
+		require.NoError(t, connection.ORM().Create(owner).Error)
+	}
+
+	owners := database.Repository[ownerMock](connection)
+
+	// Columns can be prefixed with the model table or a joined relationship
+	found, err := owners.One(database.Eq("owner_mocks.name", "ann"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, found.ID)
+
+	found, err = owners.With("Pet").One(database.Eq("Pet.name", "tom"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, found.ID)
+	assert.Equal(t, "tom", found.Pet.Name)
+
+	testcases := map[string]struct {
+		instance database.Persister[ownerMock]
+		column   string
+	}{
+		"unknown table": {
+			instance: owners,
+			column:   "anything.bogus",
+		},
+		"unknown model column": {
+			instance: owners,
+			column:   "owner_mocks.bogus",
+		},
+		"unknown relationship column": {
+			instance: owners.With("Pet"),
+			column:   "Pet.bogus",
+		},
+		"relationship not joined": {
+			instance: owners,
+			column:   "Pet.name",
+		},
+		"relationship loaded separately": {
+			instance: owners.Then("Pet"),
+			column:   "Pet.name",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := testcase.instance.Get(database.Eq(testcase.column, 1))
+			require.Error(t, err)
+
+			require.EqualError(t, err, "unable to fetch records: unknown column: "+testcase.column)
+		})
+	}
+}
+
+func TestFilters_ErrUnknownColumn(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 1))
+
+	testcases := map[string]struct {
+		where any
+	}{
+		"and": {
+			where: database.And(database.Eq("ID", 1), database.Eq("Missing", 1)),
+		},
+		"eq": {
+			where: database.Eq("Missing", 1),
+		},
+		"not": {
+			where: database.Not(database.In("Missing", 1)),
+		},
+		"or": {
+			where: database.Or{database.Eq("ID", 1), database.Like("Missing", "%")},
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			models, err := instance.Get(testcase.where)
+			require.Error(t, err)
+
+			require.EqualError(t, err, "unable to fetch records: unknown column: Missing")
+			assert.Nil(t, models)
+		})
+	}
+
+	// The error must not leak into later queries
+	models, err := instance.Get()
+	require.NoError(t, err)
+
+	assert.Len(t, models, 1)
+}
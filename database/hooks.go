@@ -0,0 +1,348 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// Change a record which was created, updated, deleted or restored.
+type Change[m Model] struct {
+	// Event after event which produced the change, AfterCreate, AfterUpdate, AfterUpsert, AfterDelete or AfterRestore
+	Event Event
+	// New record after the change, nil if the record was deleted
+	New *m
+	// Old record before the change, nil if the record was created or the stored record couldn't be found
+	Old *m
+}
+
+// Event point in a record's lifecycle where hooks run.
+type Event string
+
+// Hook function run at a point in a record's lifecycle.
+type Hook[m Model] func(ctx context.Context, model *m) error
+
+// Listener function which receives changes to records.
+type Listener[m Model] func(ctx context.Context, change Change[m])
+
+// Hooks hooks and listeners registered for each model type, shared by every repository created from a connection.
+// Connections other than Database can keep hooks by providing them from a Hooks method.
+type Hooks struct {
+	counter   uint64
+	listeners map[reflect.Type][]subscription
+	mutex     *sync.RWMutex
+	registry  map[reflect.Type]map[Event][]any
+}
+
+// hookKeeper connection which keeps hooks for every repository created from it.
+type hookKeeper interface {
+	Hooks() *Hooks
+}
+
+// pending changes made within a transaction, published once the transaction commits.
+type pending struct {
+	mutex  *sync.Mutex
+	queued []func()
+}
+
+// subscription a registered listener.
+type subscription struct {
+	id       uint64
+	listener any
+}
+
+const (
+	// AfterCreate run after a record is created.
+	AfterCreate Event = "after create"
+
+	// AfterDelete run after a record is deleted.
+	AfterDelete Event = "after delete"
+
+	// AfterRestore run after a deleted record is restored.
+	AfterRestore Event = "after restore"
+
+	// AfterUpdate run after a record is updated.
+	AfterUpdate Event = "after update"
+
+	// AfterUpsert run after a record is created or updated by an upsert.
+	AfterUpsert Event = "after upsert"
+
+	// BeforeCreate run before a record is created, returning an error aborts the create.
+	BeforeCreate Event = "before create"
+
+	// BeforeDelete run before a record is deleted, returning an error aborts the delete.
+	BeforeDelete Event = "before delete"
+
+	// BeforeRestore run before a deleted record is restored, returning an error aborts the restore.
+	BeforeRestore Event = "before restore"
+
+	// BeforeUpdate run before a record is updated, returning an error aborts the update.
+	BeforeUpdate Event = "before update"
+
+	// BeforeUpsert run before a record is created or updated by an upsert, returning an error aborts the upsert.
+	BeforeUpsert Event = "before upsert"
+)
+
+// Hooks return the hooks and listeners shared by every repository created from the connection.
+func (d *Database) Hooks() *Hooks {
+	return d.hooks
+}
+
+// Hook register a hook for every repository of the model created from the same connection, connections without Hooks
+// only share hooks between copies of the same repository. Hooks run in the order they were registered for Create,
+// CreateMany, Update, UpdateFields, Upsert, Delete and Restore, bulk writes don't load records so they don't run hooks.
+// An error returned from an after hook is returned to the caller once the write has completed, so it will only be
+// undone if the write was part of a transaction.
+func (r repository[m]) Hook(event Event, hook Hook[m]) {
+	r.hooks.mutex.Lock()
+	defer r.hooks.mutex.Unlock()
+
+	model := reflect.TypeFor[m]()
+
+	if r.hooks.registry[model] == nil {
+		r.hooks.registry[model] = make(map[Event][]any)
+	}
+
+	r.hooks.registry[model][event] = append(r.hooks.registry[model][event], hook)
+}
+
+// Subscribe receive changes to the model made by any repository created from the same connection, returning a function
+// which stops the listener. Connections without Hooks only share listeners between copies of the same repository.
+// Listeners are called synchronously once a change is written, or once the transaction commits for changes made
+// within WithTransaction, so they should return quickly.
+func (r repository[m]) Subscribe(listener Listener[m]) func() {
+	r.hooks.mutex.Lock()
+	defer r.hooks.mutex.Unlock()
+
+	model := reflect.TypeFor[m]()
+
+	r.hooks.counter++
+	id := r.hooks.counter
+
+	r.hooks.listeners[model] = append(r.hooks.listeners[model], subscription{id: id, listener: listener})
+
+	return func() {
+		r.hooks.mutex.Lock()
+		defer r.hooks.mutex.Unlock()
+
+		subscriptions := r.hooks.listeners[model]
+
+		for index, current := range subscriptions {
+			if current.id == id {
+				r.hooks.listeners[model] = append(subscriptions[:index:index], subscriptions[index+1:]...)
+
+				return
+			}
+		}
+	}
+}
+
+// lifecycle run before hooks for models, then write, then after hooks, publishing a change for each model. Stored
+// records are loaded before writing when the write replaces a stored record and something is listening or writes are
+// audited, the write and its audit entries share a transaction.
+func (r repository[m]) lifecycle(before Event, after Event, models []*m, write func(tx repository[m]) error) error {
+	ctx := r.connection.Statement.Context
+
+	for _, model := range models {
+		err := r.runHooks(ctx, before, model)
+		if err != nil {
+			return err
+		}
+	}
+
+	previous := make([]*m, len(models))
+	load := before != BeforeCreate && (r.audit != nil || r.listening())
+
+	// Only deletes and restores can be repeated without changing the outcome
+	once := before != BeforeDelete && before != BeforeRestore
+
+	err := r.atomic(once, func(tx repository[m]) error {
+		if load {
+			for index, model := range models {
+				old, err := tx.stored(model)
+				if err != nil {
+					return err
+				}
+
+				previous[index] = old
+			}
+		}
+
+		err := write(tx)
+		if err != nil {
+			return err
+		}
+
+		return tx.record(after, models, previous)
+	})
+	if err != nil {
+		return err
+	}
+
+	var failure error
+
+	for index, model := range models {
+		if failure == nil {
+			failure = r.runHooks(ctx, after, model)
+		}
+
+		// Copy the model so later changes by the caller aren't seen by listeners
+		current := *model
+
+		change := Change[m]{Event: after, New: &current, Old: previous[index]}
+		if after == AfterDelete {
+			change.New = nil
+		}
+
+		r.publish(ctx, change)
+	}
+
+	return failure
+}
+
+// listening determine whether any listeners are registered for the model.
+func (r repository[m]) listening() bool {
+	r.hooks.mutex.RLock()
+	defer r.hooks.mutex.RUnlock()
+
+	return len(r.hooks.listeners[reflect.TypeFor[m]()]) > 0
+}
+
+// stored load the stored version of a model, including deleted records, nil is returned if the model has no primary
+// key value or the record doesn't exist for the tenant.
+func (r repository[m]) stored(model *m) (*m, error) {
+	modelSchema, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := r.tenancy()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(modelSchema.PrimaryFields) == 0 {
+		return nil, nil //nolint:nilnil // Records without a primary key can't be loaded
+	}
+
+	query := UsePrimary(r.connection.Session(&gorm.Session{NewDB: true})).Unscoped() //nolint:exhaustruct // Default
+	query = scope.apply(query)
+	reflected := reflect.ValueOf(model).Elem()
+
+	for _, field := range modelSchema.PrimaryFields {
+		value, zero := field.ValueOf(r.connection.Statement.Context, reflected)
+		if zero {
+			return nil, nil //nolint:nilnil // Records without a primary key value haven't been stored
+		}
+
+		query = query.Where(clause.Eq{
+			Column: clause.Column{Alias: "", Name: field.DBName, Raw: false, Table: clause.CurrentTable},
+			Value:  value,
+		})
+	}
+
+	var old m
+
+	result := query.Take(&old)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil //nolint:nilnil // Records which don't exist have no stored version
+		}
+
+		return nil, errors.Wrap(result.Error, "unable to fetch stored record")
+	}
+
+	return &old, nil
+}
+
+// publish send a change to listeners, changes within a transaction are queued until the transaction commits.
+func (r repository[m]) publish(ctx context.Context, change Change[m]) {
+	r.hooks.mutex.RLock()
+	subscriptions := r.hooks.listeners[reflect.TypeFor[m]()]
+	r.hooks.mutex.RUnlock()
+
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	send := func() {
+		for _, current := range subscriptions {
+			listener, _ := current.listener.(Listener[m])
+			listener(ctx, change)
+		}
+	}
+
+	if r.pending == nil {
+		send()
+
+		return
+	}
+
+	r.pending.add(send)
+}
+
+// runHooks run hooks registered for an event in order, stopping at the first error.
+func (r repository[m]) runHooks(ctx context.Context, event Event, model *m) error {
+	r.hooks.mutex.RLock()
+	registered := r.hooks.registry[reflect.TypeFor[m]()][event]
+	r.hooks.mutex.RUnlock()
+
+	for _, current := range registered {
+		hook, _ := current.(Hook[m])
+
+		err := hook(ctx, model)
+		if err != nil {
+			return errors.Wrap(err, "%s hook failed", event)
+		}
+	}
+
+	return nil
+}
+
+// NewHooks create an empty hook registry.
+func NewHooks() *Hooks {
+	return &Hooks{
+		counter:   0,
+		listeners: make(map[reflect.Type][]subscription),
+		mutex:     &sync.RWMutex{},
+		registry:  make(map[reflect.Type]map[Event][]any),
+	}
+}
+
+// newPending create an empty queue of changes for a transaction.
+func newPending() *pending {
+	return &pending{mutex: &sync.Mutex{}, queued: nil}
+}
+
+// add queue a change.
+func (p *pending) add(send func()) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.queued = append(p.queued, send)
+}
+
+// flush publish queued changes, or move them to parent if the transaction is nested within another transaction.
+func (p *pending) flush(parent *pending) {
+	p.mutex.Lock()
+	queued := p.queued
+	p.queued = nil
+	p.mutex.Unlock()
+
+	if parent != nil {
+		for _, send := range queued {
+			parent.add(send)
+		}
+
+		return
+	}
+
+	for _, send := range queued {
+		send()
+	}
+}
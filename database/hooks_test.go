@@ -0,0 +1,306 @@
+package database_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/testing/database/connectionmock"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+// eventMock model used with connections other than Database.
+type eventMock struct {
+	ID   int
+	Name string
+}
+
+// TableName return the database table for this model.
+func (e eventMock) TableName() string {
+	return "event_mocks"
+}
+
+func TestRepository_Hook(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+	events := make([]string, 0)
+
+	record := func(event database.Event) database.Hook[modelmock.ModelMock] {
+		return func(_ context.Context, model *modelmock.ModelMock) error {
+			events = append(events, string(event)+" "+describe(model.ID))
+
+			return nil
+		}
+	}
+
+	for _, event := range []database.Event{
+		database.BeforeCreate,
+		database.AfterCreate,
+		database.BeforeUpdate,
+		database.AfterUpdate,
+		database.BeforeDelete,
+		database.AfterDelete,
+		database.BeforeRestore,
+		database.AfterRestore,
+		database.BeforeUpsert,
+		database.AfterUpsert,
+	} {
+		database.Repository[modelmock.ModelMock](connection).Hook(event, record(event))
+	}
+
+	// Before hooks can modify the model before it's written
+	database.Repository[modelmock.ModelMock](connection).Hook(
+		database.BeforeCreate,
+		func(_ context.Context, model *modelmock.ModelMock) error {
+			model.Test = true
+
+			return nil
+		},
+	)
+
+	repository := database.Repository[modelmock.ModelMock](connection)
+	model := &modelmock.ModelMock{}
+
+	require.NoError(t, repository.Create(model))
+	require.NoError(t, repository.Update(model))
+	require.NoError(t, repository.UpdateFields(model, "Test"))
+	require.NoError(t, repository.Delete(model))
+	require.NoError(t, repository.Restore(model))
+	require.NoError(t, repository.CreateMany([]modelmock.ModelMock{{}, {}}, 0))
+	require.NoError(t, repository.Upsert(&modelmock.ModelMock{ID: 2, Test: true}, []string{"ID"}, []string{"Test"}))
+
+	stored, err := repository.One(database.Eq("id", model.ID))
+	require.NoError(t, err)
+
+	assert.True(t, stored.Test)
+
+	assert.Equal(t, []string{
+		"before create new",
+		"after create 1",
+		"before update 1",
+		"after update 1",
+		"before update 1",
+		"after update 1",
+		"before delete 1",
+		"after delete 1",
+		"before restore 1",
+		"after restore 1",
+		"before create new",
+		"before create new",
+		"after create 2",
+		"after create 3",
+		"before upsert 2",
+		"after upsert 2",
+	}, events)
+
+	// Hooks belong to the connection they were registered on
+	other := connectFile(t)
+
+	require.NoError(t, database.Repository[modelmock.ModelMock](other).Create(&modelmock.ModelMock{}))
+
+	assert.Len(t, events, 16)
+}
+
+func TestRepository_Hook_Connection(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t, connectionmock.Options{FileBased: true})
+	require.NoError(t, connection.Migrate(eventMock{}))
+
+	other := connectionmock.New(t, connectionmock.Options{FileBased: true})
+	require.NoError(t, other.Migrate(eventMock{}))
+
+	// Connections which provide hooks share them between their repositories
+	database.Repository[eventMock](connection).Hook(database.BeforeCreate, func(_ context.Context, model *eventMock) error {
+		model.Name = "hooked"
+
+		return nil
+	})
+
+	changes := make([]database.Change[eventMock], 0)
+
+	stop := database.Repository[eventMock](connection).Subscribe(func(_ context.Context, change database.Change[eventMock]) {
+		changes = append(changes, change)
+	})
+	defer stop()
+
+	model := &eventMock{}
+	require.NoError(t, database.Repository[eventMock](connection).Create(model))
+
+	assert.Equal(t, "hooked", model.Name)
+	require.Len(t, changes, 1)
+	assert.Equal(t, database.AfterCreate, changes[0].Event)
+
+	// Hooks aren't shared with other connections
+	model = &eventMock{}
+	require.NoError(t, database.Repository[eventMock](other).Create(model))
+
+	assert.Empty(t, model.Name)
+	assert.Len(t, changes, 1)
+}
+
+func TestRepository_Hook_Errors(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+	repository := database.Repository[modelmock.ModelMock](connection)
+	errDenied := errors.New("denied")
+
+	repository.Hook(database.BeforeCreate, func(_ context.Context, model *modelmock.ModelMock) error {
+		if model.Test {
+			return errDenied
+		}
+
+		return nil
+	})
+
+	repository.Hook(database.AfterUpdate, func(_ context.Context, _ *modelmock.ModelMock) error {
+		return errDenied
+	})
+
+	// A before hook error aborts the write
+	err := repository.Create(&modelmock.ModelMock{Test: true})
+	require.ErrorIs(t, err, errDenied)
+
+	require.EqualError(t, err, "before create hook failed: denied")
+
+	err = repository.CreateMany([]modelmock.ModelMock{{}, {Test: true}}, 0)
+	require.ErrorIs(t, err, errDenied)
+
+	count, err := repository.Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(0), count)
+
+	// An after hook error is returned once the write has completed
+	model := &modelmock.ModelMock{}
+	require.NoError(t, repository.Create(model))
+
+	model.Test = true
+
+	err = repository.Update(model)
+	require.ErrorIs(t, err, errDenied)
+
+	require.EqualError(t, err, "after update hook failed: denied")
+
+	exists, err := repository.Exists(database.Eq("test", true))
+	require.NoError(t, err)
+
+	assert.True(t, exists)
+
+	// The write is undone when it's part of a transaction
+	err = connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+		return database.Repository[modelmock.ModelMock](tx).Update(&modelmock.ModelMock{ID: model.ID, Test: false})
+	})
+	require.ErrorIs(t, err, errDenied)
+
+	exists, err = repository.Exists(database.Eq("test", true))
+	require.NoError(t, err)
+
+	assert.True(t, exists)
+}
+
+func TestRepository_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+	repository := database.Repository[modelmock.ModelMock](connection)
+	changes := make([]database.Change[modelmock.ModelMock], 0)
+
+	unsubscribe := repository.Subscribe(func(_ context.Context, change database.Change[modelmock.ModelMock]) {
+		changes = append(changes, change)
+	})
+
+	model := &modelmock.ModelMock{}
+	require.NoError(t, repository.Create(model))
+
+	model.Test = true
+	require.NoError(t, repository.Update(model))
+
+	require.NoError(t, repository.Delete(model))
+	require.NoError(t, repository.Restore(model))
+
+	require.Len(t, changes, 4)
+
+	assert.Equal(t, database.AfterCreate, changes[0].Event)
+	assert.Nil(t, changes[0].Old)
+	assert.Equal(t, &modelmock.ModelMock{ID: 1}, changes[0].New)
+
+	assert.Equal(t, database.AfterUpdate, changes[1].Event)
+	assert.Equal(t, &modelmock.ModelMock{ID: 1, Test: false}, changes[1].Old)
+	assert.True(t, changes[1].New.Test)
+
+	assert.Equal(t, database.AfterDelete, changes[2].Event)
+	assert.Equal(t, 1, changes[2].Old.ID)
+	assert.Nil(t, changes[2].Old.DeletedAt)
+	assert.Nil(t, changes[2].New)
+
+	assert.Equal(t, database.AfterRestore, changes[3].Event)
+	assert.NotNil(t, changes[3].Old.DeletedAt)
+	assert.Equal(t, 1, changes[3].New.ID)
+
+	// Listeners don't receive changes once they unsubscribe
+	unsubscribe()
+	unsubscribe()
+
+	require.NoError(t, repository.Create(&modelmock.ModelMock{}))
+
+	assert.Len(t, changes, 4)
+}
+
+func TestRepository_Subscribe_Transaction(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+	changes := make([]int, 0)
+
+	database.Repository[modelmock.ModelMock](connection).Subscribe(
+		func(_ context.Context, change database.Change[modelmock.ModelMock]) {
+			changes = append(changes, change.New.ID)
+		},
+	)
+
+	err := connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+		err := database.Repository[modelmock.ModelMock](tx).Create(&modelmock.ModelMock{})
+		require.NoError(t, err)
+
+		err = tx.WithTransaction(context.Background(), func(nested database.Connection) error {
+			return database.Repository[modelmock.ModelMock](nested).Create(&modelmock.ModelMock{})
+		})
+		require.NoError(t, err)
+
+		// Changes are held until the outermost transaction commits
+		assert.Empty(t, changes)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{1, 2}, changes)
+
+	// Changes are discarded when the transaction rolls back
+	err = connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+		err := database.Repository[modelmock.ModelMock](tx).Create(&modelmock.ModelMock{})
+		require.NoError(t, err)
+
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, []int{1, 2}, changes)
+}
+
+// describe describe a model id, models which haven't been created yet are new.
+func describe(id int) string {
+	if id == 0 {
+		return "new"
+	}
+
+	return strconv.Itoa(id)
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// Iterator steps through records one at a time, records are fetched in batches so memory use doesn't grow with
+// the number of records.
+type Iterator[m Model] struct {
+	batch   []m
+	batcher *batcher[m]
+	current *m
+	err     error
+	index   int
+}
+
+// batcher fetches consecutive batches of records using keyset seeking.
+type batcher[m Model] struct {
+	after clause.Expression
+	done  bool
+	keys  keyset
+	query *gorm.DB
+	size  int
+}
+
+// defaultBatchSize number of records fetched at a time by Each and Rows.
+const defaultBatchSize = 500
+
+// Chunk call fn with batches of up to size records matching a query until every record has been processed or fn
+// returns an error. Batches are fetched using keyset seeking on the OrderBy columns and primary key, so records
+// modified by fn aren't skipped or repeated unless their keys change.
+func (r repository[m]) Chunk(size int, fn func(models []m) error, where ...any) error {
+	if size < 1 {
+		return errors.New("chunk size must be greater than zero")
+	}
+
+	batches, err := r.batcher(size, where...)
+	if err != nil {
+		return err
+	}
+
+	for {
+		models, err := batches.next()
+		if err != nil {
+			return err
+		}
+
+		if len(models) == 0 {
+			return nil
+		}
+
+		err = fn(models)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Each call fn for every record matching a query until every record has been processed or fn returns an error.
+func (r repository[m]) Each(fn func(model m) error, where ...any) error {
+	return r.Chunk(defaultBatchSize, func(models []m) error {
+		for _, model := range models {
+			err := fn(model)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, where...)
+}
+
+// Rows create an iterator over every record matching a query.
+func (r repository[m]) Rows(where ...any) (*Iterator[m], error) {
+	batches, err := r.batcher(defaultBatchSize, where...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Iterator[m]{
+		batch:   nil,
+		batcher: batches,
+		current: nil,
+		err:     nil,
+		index:   0,
+	}, nil
+}
+
+// batcher create a batcher for records matching a query.
+func (r repository[m]) batcher(size int, where ...any) (*batcher[m], error) {
+	keys, err := r.keyset()
+	if err != nil {
+		return nil, err
+	}
+
+	return &batcher[m]{
+		after: nil,
+		done:  false,
+		keys:  keys,
+		query: keys.order(r.query(where...), len(r.order)).Session(&gorm.Session{}), //nolint:exhaustruct // Reusable
+		size:  size,
+	}, nil
+}
+
+// Close stop iterating and release the current batch.
+func (i *Iterator[m]) Close() error {
+	i.batch = nil
+	i.batcher.done = true
+	i.current = nil
+	i.index = 0
+
+	return nil
+}
+
+// Err return the error which stopped iteration, if any.
+func (i *Iterator[m]) Err() error {
+	return i.err
+}
+
+// Model return the current record.
+func (i *Iterator[m]) Model() *m {
+	return i.current
+}
+
+// Next advance to the next record, false is returned when there are no more records or an error occurred.
+func (i *Iterator[m]) Next() bool {
+	if i.err != nil {
+		return false
+	}
+
+	if i.index >= len(i.batch) {
+		i.batch, i.err = i.batcher.next()
+		i.index = 0
+
+		if i.err != nil || len(i.batch) == 0 {
+			i.current = nil
+
+			return false
+		}
+	}
+
+	i.current = &i.batch[i.index]
+	i.index++
+
+	return true
+}
+
+// next fetch the next batch, an empty batch is returned once every record has been fetched.
+func (b *batcher[m]) next() ([]m, error) {
+	if b.done {
+		return nil, nil
+	}
+
+	query := b.query
+	if b.after != nil {
+		query = query.Where(b.after)
+	}
+
+	models := make([]m, 0, b.size)
+
+	result := query.Limit(b.size).Find(&models)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "unable to fetch records")
+	}
+
+	b.done = len(models) < b.size
+
+	if len(models) > 0 {
+		b.after = b.keys.seek(b.keys.values(b.query.Statement.Context, &models[len(models)-1]))
+	}
+
+	return models, nil
+}
@@ -0,0 +1,268 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+// childMock model which belongs to parentMock.
+type childMock struct {
+	ID       int
+	ParentID int
+}
+
+// parentMock model with children to preload.
+type parentMock struct {
+	Children []childMock `gorm:"foreignKey:ParentID"`
+	ID       int
+}
+
+// TableName return the database table for this model.
+func (c childMock) TableName() string {
+	return "child_mocks"
+}
+
+// TableName return the database table for this model.
+func (p parentMock) TableName() string {
+	return "parent_mocks"
+}
+
+func TestRepository_Chunk(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		size     int
+		where    []any
+		expected [][]int
+	}{
+		"exact": {
+			size:     5,
+			where:    nil,
+			expected: [][]int{{1, 2, 3, 4, 5}},
+		},
+		"partial": {
+			size:     2,
+			where:    nil,
+			expected: [][]int{{1, 2}, {3, 4}, {5}},
+		},
+		"where": {
+			size:     2,
+			where:    []any{database.Eq("test", true)},
+			expected: [][]int{{1, 3}, {5}},
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			instance := database.Repository[modelmock.ModelMock](seed(t, 5))
+
+			batches := make([][]int, 0)
+
+			err := instance.Chunk(testcase.size, func(models []modelmock.ModelMock) error {
+				batches = append(batches, ids(models))
+
+				return nil
+			}, testcase.where...)
+			require.NoError(t, err)
+
+			assert.Equal(t, testcase.expected, batches)
+		})
+	}
+}
+
+func TestRepository_Chunk_BypassDelete(t *testing.T) {
+	t.Parallel()
+
+	connection := seed(t, 4)
+
+	err := database.Repository[modelmock.ModelMock](connection).Delete(&modelmock.ModelMock{ID: 2})
+	require.NoError(t, err)
+
+	collect := func(instance database.Persister[modelmock.ModelMock]) []int {
+		found := make([]int, 0)
+
+		err := instance.Chunk(2, func(models []modelmock.ModelMock) error {
+			found = append(found, ids(models)...)
+
+			return nil
+		})
+		require.NoError(t, err)
+
+		return found
+	}
+
+	assert.Equal(t, []int{1, 3, 4}, collect(database.Repository[modelmock.ModelMock](connection)))
+	assert.Equal(t, []int{1, 2, 3, 4}, collect(database.Repository[modelmock.ModelMock](connection).BypassDelete()))
+}
+
+func TestRepository_Chunk_Errors(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 5))
+
+	err := instance.Chunk(0, func(_ []modelmock.ModelMock) error { return nil })
+	require.Error(t, err)
+
+	require.EqualError(t, err, "chunk size must be greater than zero")
+
+	calls := 0
+
+	err = instance.Chunk(2, func(_ []modelmock.ModelMock) error {
+		calls++
+
+		return errors.New("stop")
+	})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "stop")
+	assert.Equal(t, 1, calls)
+
+	err = instance.OrderBy(database.Order{Column: "missing", Descending: false}).
+		Chunk(2, func(_ []modelmock.ModelMock) error { return nil })
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to paginate on unknown column: missing")
+}
+
+func TestRepository_Chunk_Modified(t *testing.T) {
+	t.Parallel()
+
+	connection := seed(t, 5)
+	instance := database.Repository[modelmock.ModelMock](connection)
+
+	// Changing the filtered column while iterating doesn't skip records
+	found := make([]int, 0)
+
+	err := instance.Chunk(2, func(models []modelmock.ModelMock) error {
+		found = append(found, ids(models)...)
+
+		for index := range models {
+			models[index].Test = false
+
+			err := instance.UpdateFields(&models[index], "Test")
+			require.NoError(t, err)
+		}
+
+		return nil
+	}, database.Eq("test", true))
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{1, 3, 5}, found)
+}
+
+func TestRepository_Each(t *testing.T) {
+	t.Parallel()
+
+	// Odd ids are true, order by test descending then id will return odd ids first
+	instance := database.Repository[modelmock.ModelMock](seed(t, 5)).
+		OrderBy(database.Order{Column: "test", Descending: true})
+
+	found := make([]int, 0)
+
+	err := instance.Each(func(model modelmock.ModelMock) error {
+		found = append(found, model.ID)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{1, 3, 5, 2, 4}, found)
+
+	err = instance.Each(func(_ modelmock.ModelMock) error {
+		return errors.New("stop")
+	})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "stop")
+}
+
+func TestRepository_Each_Then(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+
+	err := connection.Migrate(parentMock{}, childMock{})
+	require.NoError(t, err)
+
+	parents := []parentMock{
+		{Children: []childMock{{ID: 1, ParentID: 1}, {ID: 2, ParentID: 1}}, ID: 1},
+		{Children: nil, ID: 2},
+		{Children: []childMock{{ID: 3, ParentID: 3}}, ID: 3},
+	}
+
+	err = database.Repository[parentMock](connection).CreateMany(parents, 0)
+	require.NoError(t, err)
+
+	children := make(map[int]int)
+
+	err = database.Repository[parentMock](connection).Then("Children").Each(func(model parentMock) error {
+		children[model.ID] = len(model.Children)
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[int]int{1: 2, 2: 0, 3: 1}, children)
+}
+
+func TestRepository_Rows(t *testing.T) {
+	t.Parallel()
+
+	rows, err := database.Repository[modelmock.ModelMock](seed(t, 5)).Rows(database.Gt("id", 1))
+	require.NoError(t, err)
+
+	found := make([]int, 0)
+
+	for rows.Next() {
+		found = append(found, rows.Model().ID)
+	}
+
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+
+	assert.Equal(t, []int{2, 3, 4, 5}, found)
+	assert.False(t, rows.Next())
+	assert.Nil(t, rows.Model())
+}
+
+func TestRepository_Rows_Close(t *testing.T) {
+	t.Parallel()
+
+	rows, err := database.Repository[modelmock.ModelMock](seed(t, 5)).Rows()
+	require.NoError(t, err)
+
+	require.True(t, rows.Next())
+	assert.Equal(t, 1, rows.Model().ID)
+
+	require.NoError(t, rows.Close())
+
+	assert.False(t, rows.Next())
+	assert.NoError(t, rows.Err())
+}
+
+func TestRepository_Rows_Error(t *testing.T) {
+	t.Parallel()
+
+	_, err := database.Repository[modelmock.ModelMock](seed(t, 1)).
+		OrderBy(database.Order{Column: "missing", Descending: false}).
+		Rows()
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to paginate on unknown column: missing")
+
+	rows, err := database.Repository[modelmock.ModelMock](connectFile(t)).Rows(database.Raw{Query: "missing = 1"})
+	require.NoError(t, err)
+
+	assert.False(t, rows.Next())
+	require.Error(t, rows.Err())
+
+	assert.Contains(t, rows.Err().Error(), "unable to fetch records")
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// LockMode row lock taken by queries, a strength can be combined with an option, e.g. LockUpdate|LockSkipLocked.
+type LockMode int
+
+const (
+	// LockUpdate lock rows for update, other transactions can't lock or modify them until the transaction ends.
+	LockUpdate LockMode = 1 << iota
+
+	// LockShare lock rows for reading, other transactions can read but not modify them until the transaction ends.
+	LockShare
+
+	// LockNoWait fail immediately rather than waiting for rows locked by another transaction.
+	LockNoWait
+
+	// LockSkipLocked skip rows locked by another transaction rather than waiting for them.
+	LockSkipLocked
+)
+
+// ErrLockOutsideTransaction error returned when a locking query is run outside a transaction.
+var ErrLockOutsideTransaction = errors.New("rows can only be locked within a transaction")
+
+// Lock lock rows returned by queries until the transaction ends, the repository must be part of a transaction.
+// Locks use FOR UPDATE or FOR SHARE with NOWAIT or SKIP LOCKED on MySQL and PostgreSQL, and UPDLOCK, HOLDLOCK,
+// NOWAIT and READPAST table hints on SQL Server. SQLite has no row locks and serialises writers, so locks are
+// ignored and a transaction which can't write fails with ErrDeadlock, which can be retried.
+func (r repository[m]) Lock(mode LockMode) Persister[m] {
+	transaction := r
+	transaction.lock = mode
+
+	return transaction
+}
+
+// applyLock add the locking clause for the dialect to a query.
+func (r repository[m]) applyLock(query *gorm.DB) *gorm.DB {
+	if r.lock == 0 {
+		return query
+	}
+
+	if _, ok := query.Statement.ConnPool.(gorm.TxCommitter); !ok {
+		return failed(query, ErrLockOutsideTransaction)
+	}
+
+	strength := r.lock & (LockUpdate | LockShare)
+	option := r.lock & (LockNoWait | LockSkipLocked)
+
+	if strength == LockUpdate|LockShare || option == LockNoWait|LockSkipLocked {
+		return failed(query, errors.New("lock mode must contain at most one strength and one option"))
+	}
+
+	// Locking for update is the default strength
+	if strength == 0 {
+		strength = LockUpdate
+	}
+
+	switch query.Name() {
+	case "sqlite":
+		return query
+	case "sqlserver":
+		return r.lockHints(query, strength, option)
+	}
+
+	locking := clause.Locking{
+		Strength: clause.LockingStrengthUpdate,
+		Table:    clause.Table{Alias: "", Name: "", Raw: false},
+		Options:  "",
+	}
+
+	if strength == LockShare {
+		locking.Strength = clause.LockingStrengthShare
+	}
+
+	switch option {
+	case LockNoWait:
+		locking.Options = clause.LockingOptionsNoWait
+	case LockSkipLocked:
+		locking.Options = clause.LockingOptionsSkipLocked
+	}
+
+	return query.Clauses(locking)
+}
+
+// lockHints add SQL Server table hints to a query.
+func (r repository[m]) lockHints(query *gorm.DB, strength LockMode, option LockMode) *gorm.DB {
+	modelSchema, err := r.schema()
+	if err != nil {
+		return failed(query, err)
+	}
+
+	hints := []string{"ROWLOCK", "UPDLOCK"}
+	if strength == LockShare {
+		hints = []string{"ROWLOCK", "HOLDLOCK"}
+	}
+
+	switch option {
+	case LockNoWait:
+		hints = append(hints, "NOWAIT")
+	case LockSkipLocked:
+		hints = append(hints, "READPAST")
+	}
+
+	table := clause.Table{Alias: "", Name: modelSchema.Table, Raw: false}
+
+	return query.Table("? WITH ("+strings.Join(hints, ", ")+")", table)
+}
+
+// failed add an error to a new session so the error doesn't leak into the shared connection.
+func failed(query *gorm.DB, err error) *gorm.DB {
+	query = query.Session(&gorm.Session{}) //nolint:exhaustruct // Default session
+	_ = query.AddError(err)
+
+	return query
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/database/drivers"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+// transactionMock connection pool which looks like a transaction.
+type transactionMock struct {
+	gorm.ConnPool
+}
+
+// Commit do nothing.
+func (t transactionMock) Commit() error {
+	return nil
+}
+
+// Rollback do nothing.
+func (t transactionMock) Rollback() error {
+	return nil
+}
+
+func TestRepository_Lock_Dialects(t *testing.T) {
+	t.Parallel()
+
+	sqliteDialector, err := drivers.SQLite3{Filename: ":memory:"}.GetDialector()
+	require.NoError(t, err)
+
+	dialectors := map[string]gorm.Dialector{
+		"mysql":     mysql.New(mysql.Config{DSN: "root@/test", SkipInitializeWithVersion: true}),
+		"postgres":  postgres.New(postgres.Config{DSN: "host=localhost dbname=test"}),
+		"sqlite":    sqliteDialector,
+		"sqlserver": sqlserver.New(sqlserver.Config{DSN: "sqlserver://localhost?database=test"}),
+	}
+
+	testcases := map[string]struct {
+		mode     LockMode
+		expected map[string]string
+	}{
+		"no wait": {
+			mode: LockNoWait,
+			expected: map[string]string{
+				"mysql":     "SELECT * FROM `model_mocks` WHERE `model_mocks`.`deleted_at` IS NULL FOR UPDATE NOWAIT",
+				"postgres":  `SELECT * FROM "model_mocks" WHERE "model_mocks"."deleted_at" IS NULL FOR UPDATE NOWAIT`,
+				"sqlite":    "SELECT * FROM `model_mocks` WHERE `model_mocks`.`deleted_at` IS NULL",
+				"sqlserver": `SELECT * FROM "model_mocks" WITH (ROWLOCK, UPDLOCK, NOWAIT) WHERE "model_mocks"."deleted_at" IS NULL`,
+			},
+		},
+		"share": {
+			mode: LockShare,
+			expected: map[string]string{
+				"mysql":     "SELECT * FROM `model_mocks` WHERE `model_mocks`.`deleted_at` IS NULL FOR SHARE",
+				"postgres":  `SELECT * FROM "model_mocks" WHERE "model_mocks"."deleted_at" IS NULL FOR SHARE`,
+				"sqlite":    "SELECT * FROM `model_mocks` WHERE `model_mocks`.`deleted_at` IS NULL",
+				"sqlserver": `SELECT * FROM "model_mocks" WITH (ROWLOCK, HOLDLOCK) WHERE "model_mocks"."deleted_at" IS NULL`,
+			},
+		},
+		"skip locked": {
+			mode: LockUpdate | LockSkipLocked,
+			expected: map[string]string{
+				"mysql":     "SELECT * FROM `model_mocks` WHERE `model_mocks`.`deleted_at` IS NULL FOR UPDATE SKIP LOCKED",
+				"postgres":  `SELECT * FROM "model_mocks" WHERE "model_mocks"."deleted_at" IS NULL FOR UPDATE SKIP LOCKED`,
+				"sqlite":    "SELECT * FROM `model_mocks` WHERE `model_mocks`.`deleted_at` IS NULL",
+				"sqlserver": `SELECT * FROM "model_mocks" WITH (ROWLOCK, UPDLOCK, READPAST) WHERE "model_mocks"."deleted_at" IS NULL`,
+			},
+		},
+		"update": {
+			mode: LockUpdate,
+			expected: map[string]string{
+				"mysql":     "SELECT * FROM `model_mocks` WHERE `model_mocks`.`deleted_at` IS NULL FOR UPDATE",
+				"postgres":  `SELECT * FROM "model_mocks" WHERE "model_mocks"."deleted_at" IS NULL FOR UPDATE`,
+				"sqlite":    "SELECT * FROM `model_mocks` WHERE `model_mocks`.`deleted_at` IS NULL",
+				"sqlserver": `SELECT * FROM "model_mocks" WITH (ROWLOCK, UPDLOCK) WHERE "model_mocks"."deleted_at" IS NULL`,
+			},
+		},
+	}
+
+	for name, testcase := range testcases {
+		for dialect, expected := range testcase.expected {
+			t.Run(name+"/"+dialect, func(t *testing.T) {
+				t.Parallel()
+
+				orm, err := gorm.Open(dialectors[dialect], &gorm.Config{DisableAutomaticPing: true, DryRun: true})
+				require.NoError(t, err)
+
+				transaction := orm.Session(&gorm.Session{})
+				transaction.Statement.ConnPool = transactionMock{ConnPool: transaction.Statement.ConnPool}
+
+				var statement string
+
+				err = orm.Callback().Query().After("gorm:query").Register("test:capture", func(db *gorm.DB) {
+					statement = db.Statement.SQL.String()
+				})
+				require.NoError(t, err)
+
+				_, err = Repository[modelmock.ModelMock](&Database{orm: orm}).PartOf(transaction).Lock(testcase.mode).Get()
+				require.ErrorIs(t, err, ErrNoResults)
+
+				assert.Equal(t, expected, statement)
+			})
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+func TestRepository_Lock(t *testing.T) {
+	t.Parallel()
+
+	connection := seed(t, 3)
+
+	err := connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+		instance := database.Repository[modelmock.ModelMock](tx)
+
+		model, err := instance.Lock(database.LockUpdate).One(database.Eq("id", 2))
+		require.NoError(t, err)
+
+		model.Test = true
+
+		return instance.Update(model)
+	})
+	require.NoError(t, err)
+
+	model, err := database.Repository[modelmock.ModelMock](connection).One(database.Eq("id", 2))
+	require.NoError(t, err)
+
+	assert.True(t, model.Test)
+}
+
+func TestRepository_Lock_Errors(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		mode        database.LockMode
+		transaction bool
+		expected    string
+	}{
+		"conflicting options": {
+			mode:        database.LockNoWait | database.LockSkipLocked,
+			transaction: true,
+			expected:    "unable to fetch records: lock mode must contain at most one strength and one option",
+		},
+		"conflicting strengths": {
+			mode:        database.LockShare | database.LockUpdate,
+			transaction: true,
+			expected:    "unable to fetch records: lock mode must contain at most one strength and one option",
+		},
+		"outside transaction": {
+			mode:        database.LockUpdate,
+			transaction: false,
+			expected:    "unable to fetch records: rows can only be locked within a transaction",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			connection := seed(t, 1)
+
+			get := func(connection database.Connection) error {
+				_, err := database.Repository[modelmock.ModelMock](connection).Lock(testcase.mode).Get()
+
+				return err
+			}
+
+			var err error
+
+			if testcase.transaction {
+				err = connection.WithTransaction(context.Background(), get)
+			} else {
+				err = get(connection)
+			}
+
+			require.Error(t, err)
+
+			require.EqualError(t, err, testcase.expected)
+
+			// Errors don't leak into the shared connection
+			models, err := database.Repository[modelmock.ModelMock](connection).Get()
+			require.NoError(t, err)
+
+			assert.Len(t, models, 1)
+		})
+	}
+}
+
+func TestRepository_Lock_OutsideTransaction(t *testing.T) {
+	t.Parallel()
+
+	_, err := database.Repository[modelmock.ModelMock](seed(t, 1)).Lock(database.LockShare).One()
+	require.ErrorIs(t, err, database.ErrLockOutsideTransaction)
+}
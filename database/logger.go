@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/carlmjohnson/truthy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/logging"
+)
+
+// Logging options used when logging statements.
+type Logging struct {
+	// IgnoreNotFound don't log record not found errors, these are usually handled by the caller
+	IgnoreNotFound bool
+	// Redact log statements with placeholders instead of parameter values
+	Redact bool
+	// SlowThreshold log statements which take longer than this as a warning, zero disables slow statement logging
+	SlowThreshold time.Duration
+}
+
+// queryLogger gorm logger which writes to a logging.Logger.
+type queryLogger struct {
+	level   logger.LogLevel
+	logger  logging.Logger
+	options Logging
+}
+
+// newLogger create a gorm logger from a configuration, every statement is logged when debugging.
+func newLogger(config Config) logger.Interface {
+	log := config.Logger
+	if log == nil {
+		log = logging.Default()
+	}
+
+	return &queryLogger{
+		level:   truthy.Cond(config.Debug, logger.Info, logger.Warn),
+		logger:  log,
+		options: config.Logging,
+	}
+}
+
+// Error log an error message.
+func (q *queryLogger) Error(_ context.Context, message string, replacements ...any) {
+	if q.level >= logger.Error {
+		q.logger.Error(utils.FileWithLineNum()+" "+message, replacements...)
+	}
+}
+
+// Info log an informational message.
+func (q *queryLogger) Info(_ context.Context, message string, replacements ...any) {
+	if q.level >= logger.Info {
+		q.logger.Info(utils.FileWithLineNum()+" "+message, replacements...)
+	}
+}
+
+// LogMode return a copy of the logger which logs at level.
+func (q *queryLogger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *q
+	clone.level = level
+
+	return &clone
+}
+
+// ParamsFilter remove parameters from logged statements when redacting.
+func (q *queryLogger) ParamsFilter(_ context.Context, statement string, params ...any) (string, []any) {
+	if q.options.Redact {
+		return statement, nil
+	}
+
+	return statement, params
+}
+
+// Trace log a statement, failed statements are logged as errors and slow statements as warnings.
+func (q *queryLogger) Trace(_ context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if q.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && q.level >= logger.Error && !(q.options.IgnoreNotFound && errors.Is(err, gorm.ErrRecordNotFound)):
+		statement, rows := fc()
+		q.logger.Error("%s %s [%s] [rows:%s] %s", utils.FileWithLineNum(), err, elapsed, affected(rows), statement)
+	case q.options.SlowThreshold != 0 && elapsed > q.options.SlowThreshold && q.level >= logger.Warn:
+		statement, rows := fc()
+		q.logger.Warn(
+			"%s slow statement >= %s [%s] [rows:%s] %s",
+			utils.FileWithLineNum(),
+			q.options.SlowThreshold,
+			elapsed,
+			affected(rows),
+			statement,
+		)
+	case q.level >= logger.Info:
+		statement, rows := fc()
+		q.logger.Info("%s [%s] [rows:%s] %s", utils.FileWithLineNum(), elapsed, affected(rows), statement)
+	}
+}
+
+// Warn log a warning message.
+func (q *queryLogger) Warn(_ context.Context, message string, replacements ...any) {
+	if q.level >= logger.Warn {
+		q.logger.Warn(utils.FileWithLineNum()+" "+message, replacements...)
+	}
+}
+
+// affected format the number of rows affected by a statement, -1 means the count is unknown.
+func affected(rows int64) string {
+	if rows == -1 {
+		return "-"
+	}
+
+	return strconv.FormatInt(rows, 10)
+}
@@ -0,0 +1,211 @@
+package database_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/logger"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+	"github.com/sjdaws/pkg/testing/logging/logmock"
+)
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		level    logger.LogLevel
+		expected []string
+	}{
+		"error": {
+			level:    logger.Error,
+			expected: []string{"error"},
+		},
+		"info": {
+			level:    logger.Info,
+			expected: []string{"info", "warn", "error"},
+		},
+		"silent": {
+			level:    logger.Silent,
+			expected: []string{},
+		},
+		"warn": {
+			level:    logger.Warn,
+			expected: []string{"warn", "error"},
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			log := logmock.New()
+
+			connection, err := database.Connect(database.Config{Driver: "sqlite", Logger: log, Name: ":memory:"})
+			require.NoError(t, err)
+
+			orm := connection.ORM().Logger.LogMode(testcase.level)
+			orm.Info(context.Background(), "info %s", "message")
+			orm.Warn(context.Background(), "warn %s", "message")
+			orm.Error(context.Background(), "error %s", "message")
+
+			levels := make([]string, 0)
+
+			for _, entry := range log.GetAllLogs() {
+				for level, message := range entry {
+					levels = append(levels, level)
+
+					assert.True(t, strings.HasSuffix(message, " "+level+" message"), message)
+				}
+			}
+
+			assert.Equal(t, testcase.expected, levels)
+		})
+	}
+}
+
+func TestLogger_Trace(t *testing.T) {
+	t.Parallel()
+
+	log := logmock.New()
+
+	connection, err := database.Connect(database.Config{Debug: true, Driver: "sqlite", Logger: log, Name: ":memory:"})
+	require.NoError(t, err)
+
+	err = connection.Migrate(modelmock.ModelMock{})
+	require.NoError(t, err)
+
+	result := connection.ORM().Where("test = ?", true).Find(&[]modelmock.ModelMock{})
+	require.NoError(t, result.Error)
+
+	assert.Equal(t, "info", log.GetLastLevel())
+	assert.Contains(t, log.GetLastMessage(), "[rows:0] SELECT * FROM `model_mocks` WHERE test = true")
+	assert.Contains(t, log.GetLastMessage(), "logger_test.go")
+}
+
+func TestLogger_Trace_Error(t *testing.T) {
+	t.Parallel()
+
+	log := logmock.New()
+
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Logger: log, Name: ":memory:"})
+	require.NoError(t, err)
+
+	result := connection.ORM().Find(&[]modelmock.ModelMock{})
+	require.Error(t, result.Error)
+
+	assert.Equal(t, "error", log.GetLastLevel())
+	assert.Contains(t, log.GetLastMessage(), "no such table: model_mocks")
+	assert.Contains(t, log.GetLastMessage(), "[rows:0] SELECT * FROM `model_mocks`")
+}
+
+func TestLogger_Trace_IgnoreNotFound(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		ignore   bool
+		expected int
+	}{
+		"ignored": {
+			ignore:   true,
+			expected: 0,
+		},
+		"logged": {
+			ignore:   false,
+			expected: 1,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			log := logmock.New()
+
+			connection, err := database.Connect(database.Config{
+				Driver:  "sqlite",
+				Logger:  log,
+				Logging: database.Logging{IgnoreNotFound: testcase.ignore},
+				Name:    ":memory:",
+			})
+			require.NoError(t, err)
+
+			err = connection.Migrate(modelmock.ModelMock{})
+			require.NoError(t, err)
+
+			result := connection.ORM().First(&modelmock.ModelMock{})
+			require.Error(t, result.Error)
+
+			assert.Len(t, log.GetAllLogs(), testcase.expected)
+		})
+	}
+}
+
+func TestLogger_Trace_Redact(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		redact   bool
+		expected string
+	}{
+		"redacted": {
+			redact:   true,
+			expected: "SELECT * FROM `model_mocks` WHERE id = ?",
+		},
+		"visible": {
+			redact:   false,
+			expected: "SELECT * FROM `model_mocks` WHERE id = 42",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			log := logmock.New()
+
+			connection, err := database.Connect(database.Config{
+				Debug:   true,
+				Driver:  "sqlite",
+				Logger:  log,
+				Logging: database.Logging{Redact: testcase.redact},
+				Name:    ":memory:",
+			})
+			require.NoError(t, err)
+
+			err = connection.Migrate(modelmock.ModelMock{})
+			require.NoError(t, err)
+
+			result := connection.ORM().Where("id = ?", 42).Find(&[]modelmock.ModelMock{})
+			require.NoError(t, result.Error)
+
+			assert.Contains(t, log.GetLastMessage(), testcase.expected)
+		})
+	}
+}
+
+func TestLogger_Trace_Slow(t *testing.T) {
+	t.Parallel()
+
+	log := logmock.New()
+
+	connection, err := database.Connect(database.Config{
+		Driver:  "sqlite",
+		Logger:  log,
+		Logging: database.Logging{SlowThreshold: time.Nanosecond},
+		Name:    ":memory:",
+	})
+	require.NoError(t, err)
+
+	result := connection.ORM().Exec("SELECT 1")
+	require.NoError(t, result.Error)
+
+	assert.Equal(t, "warn", log.GetLastLevel())
+	assert.Contains(t, log.GetLastMessage(), "slow statement >= 1ns")
+	assert.True(t, strings.HasSuffix(log.GetLastMessage(), "SELECT 1"), log.GetLastMessage())
+}
@@ -0,0 +1,276 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// Exporter receives every completed statement, exporters are called synchronously so should return quickly.
+type Exporter func(ctx context.Context, span Span)
+
+// Histogram latency distribution. Counts[i] is the number of statements which took at most Buckets[i] and were
+// slower than the previous bucket, the final count is the number of statements slower than every bucket.
+type Histogram struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Sum     time.Duration
+}
+
+// Metric statistics for an operation on a table.
+type Metric struct {
+	Count     uint64
+	Errors    uint64
+	Latency   Histogram
+	Operation Operation
+	Table     string
+}
+
+// Operation type of statement.
+type Operation string
+
+// Span a single completed statement.
+type Span struct {
+	Duration  time.Duration
+	Error     error
+	Operation Operation
+	Rows      int64
+	Start     time.Time
+	Statement string
+	Table     string
+	TraceID   string
+}
+
+// collector gorm plugin which records metrics and spans for every statement.
+type collector struct {
+	exporter Exporter
+	metrics  *metrics
+}
+
+// metricKey key metrics are grouped by.
+type metricKey struct {
+	operation Operation
+	table     string
+}
+
+// metrics recorded statistics.
+type metrics struct {
+	mutex  *sync.Mutex
+	values map[metricKey]*Metric
+}
+
+// traceKey context key for trace ids.
+type traceKey struct{}
+
+const (
+	// OperationCreate insert statements.
+	OperationCreate Operation = "create"
+
+	// OperationDelete delete statements, including soft deletes.
+	OperationDelete Operation = "delete"
+
+	// OperationQuery select statements.
+	OperationQuery Operation = "query"
+
+	// OperationRaw raw statements run with Exec.
+	OperationRaw Operation = "raw"
+
+	// OperationUpdate update statements.
+	OperationUpdate Operation = "update"
+)
+
+// startKey instance setting which records when a statement started.
+const startKey = "database:metrics_start"
+
+// latencyBuckets upper bounds of the latency histogram.
+//
+//nolint:gochecknoglobals // Buckets are shared by every histogram
+var latencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// TraceID return the trace id carried by ctx, or an empty string if there isn't one.
+func TraceID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	traceID, _ := ctx.Value(traceKey{}).(string)
+
+	return traceID
+}
+
+// WithTraceID return a copy of ctx carrying a trace id, spans for statements run with the context use the trace id.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceKey{}, traceID)
+}
+
+// Metrics return a snapshot of statement metrics ordered by table and operation, metrics include statements run on
+// replicas and within transactions.
+func (d *Database) Metrics() []Metric {
+	if d.metrics == nil {
+		return []Metric{}
+	}
+
+	return d.metrics.snapshot()
+}
+
+// Initialize register callbacks which time every operation. Statements are recorded after errors are translated so
+// exported spans carry the same errors as callers.
+func (c collector) Initialize(orm *gorm.DB) error {
+	callbacks := orm.Callback()
+	after := errorTranslator{}.Name()
+
+	registrations := []func() error{
+		func() error { return callbacks.Create().Before("*").Register(c.Name()+"_start", c.start) },
+		func() error { return callbacks.Create().After(after).Register(c.Name(), c.record(OperationCreate)) },
+		func() error { return callbacks.Delete().Before("*").Register(c.Name()+"_start", c.start) },
+		func() error { return callbacks.Delete().After(after).Register(c.Name(), c.record(OperationDelete)) },
+		func() error { return callbacks.Query().Before("*").Register(c.Name()+"_start", c.start) },
+		func() error { return callbacks.Query().After(after).Register(c.Name(), c.record(OperationQuery)) },
+		func() error { return callbacks.Raw().Before("*").Register(c.Name()+"_start", c.start) },
+		func() error { return callbacks.Raw().After(after).Register(c.Name(), c.record(OperationRaw)) },
+		func() error { return callbacks.Row().Before("*").Register(c.Name()+"_start", c.start) },
+		func() error { return callbacks.Row().After(after).Register(c.Name(), c.record(OperationQuery)) },
+		func() error { return callbacks.Update().Before("*").Register(c.Name()+"_start", c.start) },
+		func() error { return callbacks.Update().After(after).Register(c.Name(), c.record(OperationUpdate)) },
+	}
+
+	for _, register := range registrations {
+		err := register()
+		if err != nil {
+			return errors.Wrap(err, "unable to register metrics")
+		}
+	}
+
+	return nil
+}
+
+// Name of the plugin.
+func (c collector) Name() string {
+	return "database:metrics"
+}
+
+// record create a callback which records a completed statement.
+func (c collector) record(operation Operation) func(orm *gorm.DB) {
+	return func(orm *gorm.DB) {
+		value, ok := orm.InstanceGet(startKey)
+		if !ok {
+			return
+		}
+
+		start, _ := value.(time.Time)
+
+		span := Span{
+			Duration:  time.Since(start),
+			Error:     nil,
+			Operation: operation,
+			Rows:      orm.RowsAffected,
+			Start:     start,
+			Statement: orm.Statement.SQL.String(),
+			Table:     orm.Statement.Table,
+			TraceID:   TraceID(orm.Statement.Context),
+		}
+
+		// Missing records are a result rather than a failure
+		if orm.Error != nil && !errors.Is(orm.Error, gorm.ErrRecordNotFound) {
+			span.Error = orm.Error
+		}
+
+		c.metrics.add(span)
+
+		if c.exporter != nil {
+			c.exporter(orm.Statement.Context, span)
+		}
+	}
+}
+
+// start record when a statement started.
+func (c collector) start(orm *gorm.DB) {
+	orm.InstanceSet(startKey, time.Now())
+}
+
+// newMetrics create an empty metrics store.
+func newMetrics() *metrics {
+	return &metrics{
+		mutex:  &sync.Mutex{},
+		values: make(map[metricKey]*Metric),
+	}
+}
+
+// add a completed statement to the metrics.
+func (m *metrics) add(span Span) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := metricKey{operation: span.Operation, table: span.Table}
+
+	metric, ok := m.values[key]
+	if !ok {
+		metric = &Metric{
+			Count:  0,
+			Errors: 0,
+			Latency: Histogram{
+				Buckets: latencyBuckets,
+				Counts:  make([]uint64, len(latencyBuckets)+1),
+				Sum:     0,
+			},
+			Operation: span.Operation,
+			Table:     span.Table,
+		}
+		m.values[key] = metric
+	}
+
+	metric.Count++
+
+	if span.Error != nil {
+		metric.Errors++
+	}
+
+	bucket := sort.Search(len(latencyBuckets), func(index int) bool {
+		return span.Duration <= latencyBuckets[index]
+	})
+
+	metric.Latency.Counts[bucket]++
+	metric.Latency.Sum += span.Duration
+}
+
+// snapshot copy the metrics so they can be read without holding the lock.
+func (m *metrics) snapshot() []Metric {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	result := make([]Metric, 0, len(m.values))
+
+	for _, metric := range m.values {
+		copied := *metric
+		copied.Latency.Buckets = append([]time.Duration{}, metric.Latency.Buckets...)
+		copied.Latency.Counts = append([]uint64{}, metric.Latency.Counts...)
+
+		result = append(result, copied)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Table != result[j].Table {
+			return result[i].Table < result[j].Table
+		}
+
+		return result[i].Operation < result[j].Operation
+	})
+
+	return result
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/database/drivers"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+func TestCollector_Order(t *testing.T) {
+	t.Parallel()
+
+	dialector, err := drivers.SQLite3{Filename: ":memory:"}.GetDialector()
+	require.NoError(t, err)
+
+	orm, err := gorm.Open(dialector, &gorm.Config{})
+	require.NoError(t, err)
+
+	var spans []Span
+
+	// Statements are recorded after errors are translated even if the collector is registered first
+	err = orm.Use(collector{exporter: func(_ context.Context, span Span) { spans = append(spans, span) }, metrics: newMetrics()})
+	require.NoError(t, err)
+
+	require.NoError(t, orm.Use(errorTranslator{}))
+	require.NoError(t, orm.AutoMigrate(modelmock.ModelMock{}))
+
+	require.NoError(t, orm.Create(&modelmock.ModelMock{ID: 1}).Error)
+	require.Error(t, orm.Create(&modelmock.ModelMock{ID: 1}).Error)
+
+	require.NotEmpty(t, spans)
+	assert.ErrorIs(t, spans[len(spans)-1].Error, ErrDuplicateKey)
+}
@@ -0,0 +1,148 @@
+package database_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+func TestConnection_Metrics(t *testing.T) {
+	t.Parallel()
+
+	connection := seed(t, 2)
+
+	result := connection.ORM().Find(&[]modelmock.ModelMock{})
+	require.NoError(t, result.Error)
+
+	// Missing records aren't errors
+	result = connection.ORM().First(&modelmock.ModelMock{}, 10)
+	require.Error(t, result.Error)
+
+	result = connection.ORM().Table("missing").Find(&[]modelmock.ModelMock{})
+	require.Error(t, result.Error)
+
+	err := connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+		return tx.ORM().Model(&modelmock.ModelMock{}).Where("id = ?", 1).Update("test", false).Error
+	})
+	require.NoError(t, err)
+
+	metrics := connection.Metrics()
+
+	create := find(t, metrics, "model_mocks", database.OperationCreate)
+	assert.Equal(t, uint64(2), create.Count)
+	assert.Equal(t, uint64(0), create.Errors)
+
+	query := find(t, metrics, "model_mocks", database.OperationQuery)
+	assert.Equal(t, uint64(2), query.Count)
+	assert.Equal(t, uint64(0), query.Errors)
+
+	missing := find(t, metrics, "missing", database.OperationQuery)
+	assert.Equal(t, uint64(1), missing.Count)
+	assert.Equal(t, uint64(1), missing.Errors)
+
+	update := find(t, metrics, "model_mocks", database.OperationUpdate)
+	assert.Equal(t, uint64(1), update.Count)
+
+	var total uint64
+	for _, count := range query.Latency.Counts {
+		total += count
+	}
+
+	assert.Equal(t, query.Count, total)
+	assert.Len(t, query.Latency.Counts, len(query.Latency.Buckets)+1)
+	assert.Positive(t, query.Latency.Sum)
+}
+
+func TestConnection_Metrics_Exporter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mutex sync.Mutex
+		spans []database.Span
+	)
+
+	connection, err := database.Connect(database.Config{
+		Driver: "sqlite",
+		Exporter: func(_ context.Context, span database.Span) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			spans = append(spans, span)
+		},
+		Name: ":memory:",
+	})
+	require.NoError(t, err)
+
+	err = connection.Migrate(modelmock.ModelMock{})
+	require.NoError(t, err)
+
+	ctx := database.WithTraceID(context.Background(), "trace")
+
+	result := connection.ORM().WithContext(ctx).Where("test = ?", true).Find(&[]modelmock.ModelMock{})
+	require.NoError(t, result.Error)
+
+	result = connection.ORM().Exec("DELETE FROM model_mocks")
+	require.NoError(t, result.Error)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	require.GreaterOrEqual(t, len(spans), 2)
+
+	query := spans[len(spans)-2]
+	assert.Equal(t, database.OperationQuery, query.Operation)
+	assert.Equal(t, "model_mocks", query.Table)
+	assert.Equal(t, "trace", query.TraceID)
+	assert.Equal(t, "SELECT * FROM `model_mocks` WHERE test = ? AND `model_mocks`.`deleted_at` IS NULL", query.Statement)
+	assert.NoError(t, query.Error)
+	assert.False(t, query.Start.IsZero())
+
+	raw := spans[len(spans)-1]
+	assert.Equal(t, database.OperationRaw, raw.Operation)
+	assert.Empty(t, raw.TraceID)
+	assert.Equal(t, "DELETE FROM model_mocks", raw.Statement)
+}
+
+func TestConnection_Metrics_Transaction(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+
+	err := connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+		err := database.Repository[modelmock.ModelMock](tx).Create(&modelmock.ModelMock{Test: true})
+		require.NoError(t, err)
+
+		assert.Equal(t, uint64(1), find(t, tx.Metrics(), "model_mocks", database.OperationCreate).Count)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestTraceID(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, database.TraceID(context.Background()))
+	assert.Equal(t, "trace", database.TraceID(database.WithTraceID(context.Background(), "trace")))
+}
+
+// find a metric by table and operation.
+func find(t *testing.T, metrics []database.Metric, table string, operation database.Operation) database.Metric {
+	t.Helper()
+
+	for _, metric := range metrics {
+		if metric.Table == table && metric.Operation == operation {
+			return metric
+		}
+	}
+
+	require.Failf(t, "metric not found", "%s %s", table, operation)
+
+	return database.Metric{}
+}
@@ -0,0 +1,180 @@
+package migrations
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/io"
+)
+
+// Migration a numbered schema change which can be applied and optionally reverted.
+type Migration struct {
+	Down    Step
+	Name    string
+	Up      Step
+	Version int64
+}
+
+// Step function which applies or reverts a migration, tx is a transaction which is committed if nil is returned.
+type Step func(tx database.Connection) error
+
+// Patterns used when loading and splitting migrations.
+//
+//nolint:gochecknoglobals // Patterns are compiled once
+var (
+	blockCommentPattern = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	dollarQuotePattern  = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+	filenamePattern     = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+	lineCommentPattern  = regexp.MustCompile(`(?m)^\s*--.*$`)
+)
+
+// Load read SQL migrations from a directory. Files must be named <version>_<name>.up.sql and
+// <version>_<name>.down.sql, down files are optional and other files are ignored.
+func Load(reader io.Reader, directory string) ([]Migration, error) {
+	files, err := reader.List(directory)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list migrations")
+	}
+
+	found := make(map[int64]*Migration)
+
+	for _, file := range files {
+		matches := filenamePattern.FindStringSubmatch(file.Name())
+		if file.IsDir() || matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid migration version: %s", file.Name())
+		}
+
+		contents, err := reader.Read(filepath.Join(directory, file.Name()))
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read migration: %s", file.Name())
+		}
+
+		migration, ok := found[version]
+		if !ok {
+			migration = &Migration{Down: nil, Name: matches[2], Up: nil, Version: version}
+			found[version] = migration
+		}
+
+		if migration.Name != matches[2] {
+			return nil, errors.New("migration %d has conflicting names: %s and %s", version, migration.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			migration.Up = SQL(string(contents))
+		} else {
+			migration.Down = SQL(string(contents))
+		}
+	}
+
+	migrations := make([]Migration, 0, len(found))
+
+	for version, migration := range found {
+		if migration.Up == nil {
+			return nil, errors.New("migration %d is missing an up file", version)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// SQL create a step which executes SQL, multiple statements are separated by semicolons and run in order.
+func SQL(contents string) Step {
+	statements := split(contents)
+
+	return func(tx database.Connection) error {
+		for _, statement := range statements {
+			result := tx.ORM().Exec(statement)
+			if result.Error != nil {
+				return errors.Wrap(result.Error, "unable to execute statement")
+			}
+		}
+
+		return nil
+	}
+}
+
+// appendStatement add a statement if it contains more than whitespace and comments.
+func appendStatement(statements []string, statement string) []string {
+	statement = strings.TrimSpace(statement)
+
+	meaningful := lineCommentPattern.ReplaceAllString(statement, "")
+	meaningful = blockCommentPattern.ReplaceAllString(meaningful, "")
+
+	if strings.TrimSpace(meaningful) == "" {
+		return statements
+	}
+
+	return append(statements, statement)
+}
+
+// split SQL into statements on semicolons which aren't within quotes, dollar quotes or comments.
+func split(contents string) []string {
+	statements := make([]string, 0)
+
+	var (
+		current strings.Builder
+		quote   string
+	)
+
+	for index := 0; index < len(contents); index++ {
+		character := contents[index]
+		remaining := contents[index:]
+
+		switch {
+		case quote != "":
+			// Inside a quote, only look for the closing quote
+			if strings.HasPrefix(remaining, quote) {
+				current.WriteString(quote)
+				index += len(quote) - 1
+				quote = ""
+
+				continue
+			}
+
+		case strings.HasPrefix(remaining, "--"):
+			quote = "\n"
+
+		case strings.HasPrefix(remaining, "/*"):
+			quote = "*/"
+
+		case character == '\'' || character == '"' || character == '`':
+			quote = string(character)
+
+		case character == '$':
+			// Dollar quotes, e.g. $$ or $body$, are used by PostgreSQL for function bodies
+			if tag := dollarQuotePattern.FindString(remaining); tag != "" {
+				quote = tag
+				current.WriteString(tag)
+				index += len(tag) - 1
+
+				continue
+			}
+
+		case character == ';':
+			statements = appendStatement(statements, current.String())
+			current.Reset()
+
+			continue
+		}
+
+		current.WriteByte(character)
+	}
+
+	return appendStatement(statements, current.String())
+}
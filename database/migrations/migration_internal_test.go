@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		contents string
+		expected []string
+	}{
+		"backticks": {
+			contents: "SELECT `a;b` FROM t; SELECT 1",
+			expected: []string{"SELECT `a;b` FROM t", "SELECT 1"},
+		},
+		"block comment": {
+			contents: "/* a; b */ SELECT 1; /* only a comment; */",
+			expected: []string{"/* a; b */ SELECT 1"},
+		},
+		"dollar quotes": {
+			contents: "CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql; SELECT $$a;b$$",
+			expected: []string{
+				"CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql",
+				"SELECT $$a;b$$",
+			},
+		},
+		"double quotes": {
+			contents: `SELECT "a;b"; SELECT 2`,
+			expected: []string{`SELECT "a;b"`, "SELECT 2"},
+		},
+		"empty": {
+			contents: " ;\n; ",
+			expected: []string{},
+		},
+		"line comment": {
+			contents: "-- first; statement\nSELECT 1;\n-- trailing; comment",
+			expected: []string{"-- first; statement\nSELECT 1"},
+		},
+		"parameters": {
+			contents: "SELECT $1; SELECT 2",
+			expected: []string{"SELECT $1", "SELECT 2"},
+		},
+		"single quotes": {
+			contents: "SELECT 'it''s;'; SELECT 2;",
+			expected: []string{"SELECT 'it''s;'", "SELECT 2"},
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testcase.expected, split(testcase.contents))
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database/migrations"
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/io/filesystem"
+	"github.com/sjdaws/pkg/testing/io/readwritermock"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	reader := readwritermock.New(afero.NewMemMapFs())
+
+	files := map[string]string{
+		"migrations/0001_create_widgets.up.sql":   "CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT);",
+		"migrations/0001_create_widgets.down.sql": "DROP TABLE widgets;",
+		"migrations/0002_seed_widgets.up.sql":     "INSERT INTO widgets (name) VALUES ('a;b'); INSERT INTO widgets (name) VALUES ('c');",
+		"migrations/README.md":                    "ignored",
+	}
+
+	for filename, contents := range files {
+		require.NoError(t, reader.Write(filename, []byte(contents)))
+	}
+
+	result, err := migrations.Load(reader, "migrations")
+	require.NoError(t, err)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, int64(1), result[0].Version)
+	assert.Equal(t, "create_widgets", result[0].Name)
+	assert.NotNil(t, result[0].Down)
+	assert.Equal(t, int64(2), result[1].Version)
+	assert.Equal(t, "seed_widgets", result[1].Name)
+	assert.Nil(t, result[1].Down)
+
+	connection := connect(t)
+
+	require.NoError(t, result[0].Up(connection))
+	require.NoError(t, result[1].Up(connection))
+
+	names := make([]string, 0)
+	require.NoError(t, connection.ORM().Raw("SELECT name FROM widgets ORDER BY id").Scan(&names).Error)
+
+	assert.Equal(t, []string{"a;b", "c"}, names)
+
+	require.NoError(t, result[0].Down(connection))
+	assert.False(t, connection.ORM().Migrator().HasTable("widgets"))
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		files    map[string]string
+		listErr  error
+		readErr  error
+		expected string
+	}{
+		"conflicting names": {
+			files: map[string]string{
+				"migrations/0001_create.up.sql":  "SELECT 1",
+				"migrations/0001_other.down.sql": "SELECT 1",
+			},
+			expected: "migration 1 has conflicting names: ",
+		},
+		"list error": {
+			listErr:  errors.New("list"),
+			expected: "unable to list migrations: list",
+		},
+		"missing up": {
+			files: map[string]string{
+				"migrations/0001_create.down.sql": "SELECT 1",
+			},
+			expected: "migration 1 is missing an up file",
+		},
+		"read error": {
+			files: map[string]string{
+				"migrations/0001_create.up.sql": "SELECT 1",
+			},
+			readErr:  errors.New("read"),
+			expected: "unable to read migration: 0001_create.up.sql: read",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			reader := readwritermock.New(afero.NewMemMapFs())
+			require.NoError(t, reader.Mkdir("migrations"))
+
+			for filename, contents := range testcase.files {
+				require.NoError(t, reader.Write(filename, []byte(contents)))
+			}
+
+			reader.ListError = testcase.listErr
+			reader.ReadError = testcase.readErr
+
+			result, err := migrations.Load(reader, "migrations")
+			require.Error(t, err)
+
+			require.ErrorContains(t, err, testcase.expected)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func TestLoad_Filesystem(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+	reader := filesystem.Default()
+
+	require.NoError(t, reader.Write(directory+"/10_later.up.sql", []byte("SELECT 1")))
+	require.NoError(t, reader.Write(directory+"/9_earlier.up.sql", []byte("SELECT 1")))
+
+	result, err := migrations.Load(reader, directory)
+	require.NoError(t, err)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, int64(9), result[0].Version)
+	assert.Equal(t, int64(10), result[1].Version)
+}
+
+func TestSQL(t *testing.T) {
+	t.Parallel()
+
+	connection := connect(t)
+
+	step := migrations.SQL(`
+-- Widgets table; stores widgets
+CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT, note TEXT);
+
+/* Seed data; block comment */
+INSERT INTO widgets (name, note) VALUES ('it''s; fine', 'quoted');
+INSERT INTO widgets (name, note) VALUES ('second', NULL);
+-- trailing comment;
+`)
+
+	require.NoError(t, step(connection))
+
+	var count int64
+	require.NoError(t, connection.ORM().Raw("SELECT COUNT(*) FROM widgets").Scan(&count).Error)
+
+	assert.Equal(t, int64(2), count)
+}
+
+func TestSQL_Error(t *testing.T) {
+	t.Parallel()
+
+	step := migrations.SQL("SELECT * FROM missing")
+
+	err := step(connect(t))
+	require.Error(t, err)
+
+	require.ErrorContains(t, err, "unable to execute statement: ")
+}
@@ -0,0 +1,337 @@
+package migrations
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/errors"
+)
+
+// Migrator applies and reverts migrations, tracking applied migrations in the schema_migrations table.
+type Migrator struct {
+	connection database.Connection
+	migrations []Migration
+}
+
+// Status of a migration.
+type Status struct {
+	Applied   bool
+	AppliedAt *time.Time
+	Name      string
+	Version   int64
+}
+
+// schemaLock row which exists while migrations are running.
+type schemaLock struct {
+	ID       int `gorm:"primaryKey;autoIncrement:false"`
+	LockedAt time.Time
+}
+
+// schemaMigration row recording an applied migration.
+type schemaMigration struct {
+	AppliedAt time.Time
+	Name      string
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+}
+
+// ErrLocked error returned when migrations are already being run by another process.
+var ErrLocked = errors.New("migrations are locked by another process")
+
+// New create a migrator for migrations, versions must be unique.
+func New(connection database.Connection, migrations ...Migration) (*Migrator, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	for index, migration := range sorted {
+		if migration.Up == nil {
+			return nil, errors.New("migration %d must have an up step", migration.Version)
+		}
+
+		if index > 0 && sorted[index-1].Version == migration.Version {
+			return nil, errors.New("migration %d is defined more than once", migration.Version)
+		}
+	}
+
+	return &Migrator{
+		connection: connection,
+		migrations: sorted,
+	}, nil
+}
+
+// TableName return the database table for applied migrations.
+func (s schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// TableName return the database table for the migration lock.
+func (s schemaLock) TableName() string {
+	return "schema_migrations_lock"
+}
+
+// Down revert the most recently applied migrations, one migration is reverted per step.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps < 1 {
+		return errors.New("steps must be greater than zero")
+	}
+
+	return m.locked(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		steps = min(steps, len(applied))
+
+		for index := range steps {
+			err = m.revert(ctx, applied[len(applied)-1-index].Version)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Redo revert and reapply the most recently applied migration.
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.locked(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			return errors.New("no migrations have been applied")
+		}
+
+		version := applied[len(applied)-1].Version
+
+		err = m.revert(ctx, version)
+		if err != nil {
+			return err
+		}
+
+		return m.apply(ctx, version)
+	})
+}
+
+// Status of every known migration in version order, including applied migrations which are no longer defined.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	err := m.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[int64]Status, len(m.migrations))
+
+	for _, migration := range m.migrations {
+		statuses[migration.Version] = Status{Applied: false, AppliedAt: nil, Name: migration.Name, Version: migration.Version}
+	}
+
+	for _, record := range applied {
+		appliedAt := record.AppliedAt
+		statuses[record.Version] = Status{Applied: true, AppliedAt: &appliedAt, Name: record.Name, Version: record.Version}
+	}
+
+	result := make([]Status, 0, len(statuses))
+	for _, status := range statuses {
+		result = append(result, status)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result, nil
+}
+
+// Unlock remove a lock left behind by a process which exited while running migrations.
+func (m *Migrator) Unlock(ctx context.Context) error {
+	err := m.prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := m.connection.ORM().WithContext(ctx).Delete(&schemaLock{ID: 1, LockedAt: time.Time{}})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "unable to remove migration lock")
+	}
+
+	return nil
+}
+
+// Up apply every pending migration in version order.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.locked(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		done := make(map[int64]bool, len(applied))
+		for _, record := range applied {
+			done[record.Version] = true
+		}
+
+		for _, migration := range m.migrations {
+			if done[migration.Version] {
+				continue
+			}
+
+			err = m.apply(ctx, migration.Version)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// apply a migration and record it as applied in the same transaction.
+func (m *Migrator) apply(ctx context.Context, version int64) error {
+	migration, ok := m.find(version)
+	if !ok {
+		return errors.New("migration %d is not defined", version)
+	}
+
+	err := m.connection.WithTransaction(ctx, func(tx database.Connection) error {
+		err := migration.Up(tx)
+		if err != nil {
+			return err
+		}
+
+		record := schemaMigration{AppliedAt: time.Now().UTC(), Name: migration.Name, Version: migration.Version}
+
+		result := tx.ORM().Create(&record)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "unable to record migration")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to apply migration %d_%s", migration.Version, migration.Name)
+	}
+
+	return nil
+}
+
+// applied migrations in version order.
+func (m *Migrator) applied(ctx context.Context) ([]schemaMigration, error) {
+	records := make([]schemaMigration, 0)
+
+	result := database.UsePrimary(m.connection.ORM().WithContext(ctx)).Order("version asc").Find(&records)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "unable to fetch applied migrations")
+	}
+
+	return records, nil
+}
+
+// find a migration by version.
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+// locked run fn while holding the migration lock.
+func (m *Migrator) locked(ctx context.Context, fn func() error) error {
+	err := m.prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	orm := m.connection.ORM().WithContext(ctx)
+
+	// The lock table has a single possible row so only one process can insert it, the insert is skipped rather than
+	// failing if the row exists so contention is detected without relying on how the driver reports duplicate keys
+	conflict := clause.OnConflict{
+		Columns:      []clause.Column{{Alias: "", Name: "id", Raw: false, Table: ""}},
+		Where:        clause.Where{Exprs: nil},
+		TargetWhere:  clause.Where{Exprs: nil},
+		OnConstraint: "",
+		DoNothing:    true,
+		DoUpdates:    nil,
+		UpdateAll:    false,
+	}
+
+	result := orm.Clauses(conflict).Create(&schemaLock{ID: 1, LockedAt: time.Now().UTC()})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "unable to lock migrations")
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrLocked
+	}
+
+	failure := fn()
+
+	// Release the lock even if the context has been cancelled
+	result = m.connection.ORM().WithContext(context.WithoutCancel(ctx)).Delete(&schemaLock{ID: 1, LockedAt: time.Time{}})
+	if result.Error != nil && failure == nil {
+		return errors.Wrap(result.Error, "unable to unlock migrations")
+	}
+
+	return failure
+}
+
+// prepare create the bookkeeping tables.
+func (m *Migrator) prepare(ctx context.Context) error {
+	err := m.connection.MigrateContext(ctx, &schemaMigration{}, &schemaLock{})
+	if err != nil {
+		return errors.Wrap(err, "unable to create migration tables")
+	}
+
+	return nil
+}
+
+// revert a migration and remove its record in the same transaction.
+func (m *Migrator) revert(ctx context.Context, version int64) error {
+	migration, ok := m.find(version)
+	if !ok {
+		return errors.New("migration %d is applied but not defined", version)
+	}
+
+	if migration.Down == nil {
+		return errors.New("migration %d_%s can't be reverted", migration.Version, migration.Name)
+	}
+
+	err := m.connection.WithTransaction(ctx, func(tx database.Connection) error {
+		err := migration.Down(tx)
+		if err != nil {
+			return err
+		}
+
+		result := tx.ORM().Delete(&schemaMigration{AppliedAt: time.Time{}, Name: "", Version: version})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "unable to remove migration record")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to revert migration %d_%s", migration.Version, migration.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,279 @@
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/database/migrations"
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/testing/database/connectionmock"
+)
+
+func TestMigrator(t *testing.T) {
+	t.Parallel()
+
+	connection := connect(t)
+
+	migrator, err := migrations.New(connection, definitions()...)
+	require.NoError(t, err)
+
+	statuses, err := migrator.Status(t.Context())
+	require.NoError(t, err)
+
+	assert.Equal(t, []bool{false, false, false}, applied(statuses))
+
+	require.NoError(t, migrator.Up(t.Context()))
+
+	statuses, err = migrator.Status(t.Context())
+	require.NoError(t, err)
+
+	assert.Equal(t, []bool{true, true, true}, applied(statuses))
+	assert.Equal(t, "create_widgets", statuses[0].Name)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Equal(t, []string{"name", "colour"}, columns(t, connection))
+
+	// Running again does nothing
+	require.NoError(t, migrator.Up(t.Context()))
+
+	require.NoError(t, migrator.Down(t.Context(), 1))
+	assert.Equal(t, []string{"name"}, columns(t, connection))
+
+	require.NoError(t, migrator.Redo(t.Context()))
+	assert.Equal(t, []string{"name"}, columns(t, connection))
+
+	statuses, err = migrator.Status(t.Context())
+	require.NoError(t, err)
+
+	assert.Equal(t, []bool{true, true, false}, applied(statuses))
+
+	require.NoError(t, migrator.Down(t.Context(), 10))
+	assert.False(t, connection.ORM().Migrator().HasTable("widgets"))
+
+	statuses, err = migrator.Status(t.Context())
+	require.NoError(t, err)
+
+	assert.Equal(t, []bool{false, false, false}, applied(statuses))
+}
+
+func TestMigrator_Errors(t *testing.T) {
+	t.Parallel()
+
+	irreversible := migrations.Migration{Down: nil, Name: "irreversible", Up: migrations.SQL("SELECT 1"), Version: 1}
+	failing := migrations.Migration{
+		Down:    nil,
+		Name:    "failing",
+		Up:      func(_ database.Connection) error { return errors.New("test") },
+		Version: 2,
+	}
+
+	testcases := map[string]struct {
+		call       func(migrator *migrations.Migrator) error
+		migrations []migrations.Migration
+		expected   string
+	}{
+		"down irreversible": {
+			call: func(migrator *migrations.Migrator) error {
+				require.NoError(t, migrator.Up(t.Context()))
+
+				return migrator.Down(t.Context(), 1)
+			},
+			migrations: []migrations.Migration{irreversible},
+			expected:   "migration 1_irreversible can't be reverted",
+		},
+		"down zero steps": {
+			call: func(migrator *migrations.Migrator) error {
+				return migrator.Down(t.Context(), 0)
+			},
+			expected: "steps must be greater than zero",
+		},
+		"redo nothing applied": {
+			call: func(migrator *migrations.Migrator) error {
+				return migrator.Redo(t.Context())
+			},
+			expected: "no migrations have been applied",
+		},
+		"up failure": {
+			call: func(migrator *migrations.Migrator) error {
+				return migrator.Up(t.Context())
+			},
+			migrations: []migrations.Migration{irreversible, failing},
+			expected:   "unable to apply migration 2_failing: test",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			migrator, err := migrations.New(connect(t), testcase.migrations...)
+			require.NoError(t, err)
+
+			err = testcase.call(migrator)
+			require.Error(t, err)
+
+			require.EqualError(t, err, testcase.expected)
+		})
+	}
+}
+
+func TestMigrator_Lock(t *testing.T) {
+	t.Parallel()
+
+	connection := connect(t)
+
+	migrator, err := migrations.New(connection, definitions()...)
+	require.NoError(t, err)
+
+	// Simulate another process holding the lock
+	require.NoError(t, migrator.Unlock(t.Context()))
+	require.NoError(t, connection.ORM().Exec("INSERT INTO schema_migrations_lock (id, locked_at) VALUES (1, CURRENT_TIMESTAMP)").Error)
+
+	err = migrator.Up(t.Context())
+	require.ErrorIs(t, err, migrations.ErrLocked)
+
+	require.NoError(t, migrator.Unlock(t.Context()))
+	require.NoError(t, migrator.Up(t.Context()))
+
+	// Lock is released after running
+	require.NoError(t, migrator.Up(t.Context()))
+}
+
+func TestMigrator_Lock_Connection(t *testing.T) {
+	t.Parallel()
+
+	// Connections which don't translate driver errors still detect the lock
+	connection := connectionmock.New(t, connectionmock.Options{FileBased: true})
+
+	migrator, err := migrations.New(connection, definitions()...)
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Unlock(t.Context()))
+	require.NoError(t, connection.ORM().Exec("INSERT INTO schema_migrations_lock (id, locked_at) VALUES (1, CURRENT_TIMESTAMP)").Error)
+
+	err = migrator.Up(t.Context())
+	require.ErrorIs(t, err, migrations.ErrLocked)
+}
+
+func TestMigrator_UnknownApplied(t *testing.T) {
+	t.Parallel()
+
+	connection := connect(t)
+
+	migrator, err := migrations.New(connection, definitions()...)
+	require.NoError(t, err)
+
+	require.NoError(t, migrator.Up(t.Context()))
+
+	// An older release of the application which doesn't know about the latest migration
+	older, err := migrations.New(connection, definitions()[0], definitions()[2])
+	require.NoError(t, err)
+
+	statuses, err := older.Status(t.Context())
+	require.NoError(t, err)
+
+	assert.Equal(t, []bool{true, true, true}, applied(statuses))
+	assert.Equal(t, "add_colour", statuses[2].Name)
+
+	err = older.Down(t.Context(), 1)
+	require.EqualError(t, err, "migration 3 is applied but not defined")
+}
+
+func TestNew_Errors(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		migrations []migrations.Migration
+		expected   string
+	}{
+		"duplicate version": {
+			migrations: []migrations.Migration{definitions()[0], definitions()[0]},
+			expected:   "migration 1 is defined more than once",
+		},
+		"missing up": {
+			migrations: []migrations.Migration{{Down: nil, Name: "empty", Up: nil, Version: 1}},
+			expected:   "migration 1 must have an up step",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			migrator, err := migrations.New(connect(t), testcase.migrations...)
+			require.Error(t, err)
+
+			require.EqualError(t, err, testcase.expected)
+			assert.Nil(t, migrator)
+		})
+	}
+}
+
+// applied whether each migration has been applied.
+func applied(statuses []migrations.Status) []bool {
+	result := make([]bool, 0, len(statuses))
+
+	for _, status := range statuses {
+		result = append(result, status.Applied)
+	}
+
+	return result
+}
+
+// columns in the widgets table excluding the primary key.
+func columns(t *testing.T, connection database.Connection) []string {
+	t.Helper()
+
+	types, err := connection.ORM().Migrator().ColumnTypes("widgets")
+	require.NoError(t, err)
+
+	result := make([]string, 0, len(types))
+
+	for _, column := range types {
+		if column.Name() != "id" {
+			result = append(result, column.Name())
+		}
+	}
+
+	return result
+}
+
+// connect create a file based database so every connection in the pool sees the same data.
+func connect(t *testing.T) *database.Database {
+	t.Helper()
+
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: t.TempDir() + "/test.db"})
+	require.NoError(t, err)
+
+	return connection
+}
+
+// definitions migrations mixing SQL and Go steps, defined out of order.
+func definitions() []migrations.Migration {
+	return []migrations.Migration{
+		{
+			Down:    migrations.SQL("DROP TABLE widgets"),
+			Name:    "create_widgets",
+			Up:      migrations.SQL("CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT)"),
+			Version: 1,
+		},
+		{
+			Down: func(tx database.Connection) error {
+				return tx.ORM().Exec("ALTER TABLE widgets DROP COLUMN colour").Error
+			},
+			Name: "add_colour",
+			Up: func(tx database.Connection) error {
+				return tx.ORM().Exec("ALTER TABLE widgets ADD COLUMN colour TEXT").Error
+			},
+			Version: 3,
+		},
+		{
+			Down:    migrations.SQL("DELETE FROM widgets"),
+			Name:    "seed_widgets",
+			Up:      migrations.SQL("INSERT INTO widgets (name) VALUES ('first'); INSERT INTO widgets (name) VALUES ('second')"),
+			Version: 2,
+		},
+	}
+}
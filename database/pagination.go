@@ -0,0 +1,250 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// CursorPage a page of records fetched using keyset pagination.
+type CursorPage[m Model] struct {
+	HasNext bool
+	Items   []m
+	Next    string
+}
+
+// Page a page of records fetched using offset pagination.
+type Page[m Model] struct {
+	HasNext bool
+	Items   []m
+	Page    int
+	Pages   int
+	Size    int
+	Total   int64
+}
+
+// key column used to order and seek records during keyset pagination.
+type key struct {
+	descending bool
+	field      *schema.Field
+}
+
+// keyset ordered keys used for keyset pagination.
+type keyset []key
+
+// ErrInvalidCursor error returned when a pagination cursor can't be decoded.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// Cursor fetch a page of records following cursor using keyset pagination on the OrderBy columns, an empty cursor
+// fetches the first page. The primary key is always used as the final key so pages are stable.
+func (r repository[m]) Cursor(cursor string, size int, where ...any) (CursorPage[m], error) {
+	if size < 1 {
+		return CursorPage[m]{}, errors.New("page size must be greater than zero")
+	}
+
+	keys, err := r.keyset()
+	if err != nil {
+		return CursorPage[m]{}, err
+	}
+
+	query := keys.order(r.query(where...), len(r.order))
+
+	if cursor != "" {
+		condition, err := keys.decode(cursor)
+		if err != nil {
+			return CursorPage[m]{}, err
+		}
+
+		query = query.Where(condition)
+	}
+
+	models := make([]m, 0, size+1)
+
+	result := query.Limit(size + 1).Find(&models)
+	if result.Error != nil {
+		return CursorPage[m]{}, errors.Wrap(result.Error, "unable to fetch records")
+	}
+
+	page := CursorPage[m]{
+		HasNext: len(models) > size,
+		Items:   models,
+		Next:    "",
+	}
+
+	if page.HasNext {
+		page.Items = models[:size]
+
+		page.Next, err = keys.encode(query.Statement.Context, &page.Items[size-1])
+		if err != nil {
+			return CursorPage[m]{}, err
+		}
+	}
+
+	return page, nil
+}
+
+// Paginate fetch a page of records using offset pagination, pages start at 1.
+func (r repository[m]) Paginate(page int, size int, where ...any) (Page[m], error) {
+	if page < 1 || size < 1 {
+		return Page[m]{}, errors.New("page and page size must be greater than zero")
+	}
+
+	var total int64
+
+	result := r.scope(where...).Count(&total)
+	if result.Error != nil {
+		return Page[m]{}, errors.Wrap(result.Error, "unable to count records")
+	}
+
+	models := make([]m, 0, size)
+
+	result = r.query(where...).Offset((page - 1) * size).Limit(size).Find(&models)
+	if result.Error != nil {
+		return Page[m]{}, errors.Wrap(result.Error, "unable to fetch records")
+	}
+
+	pages := int((total + int64(size) - 1) / int64(size))
+
+	return Page[m]{
+		HasNext: page < pages,
+		Items:   models,
+		Page:    page,
+		Pages:   pages,
+		Size:    size,
+		Total:   total,
+	}, nil
+}
+
+// keyset determine the keys used for keyset pagination from the requested order and primary key.
+func (r repository[m]) keyset() (keyset, error) {
+	modelSchema, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(keyset, 0, len(r.order)+1)
+	primary := modelSchema.PrioritizedPrimaryField
+	ordered := false
+
+	for _, by := range r.order {
+		// Allow columns to be prefixed with a table name
+		column := by.Column[strings.LastIndex(by.Column, ".")+1:]
+
+		field := modelSchema.LookUpField(column)
+		if field == nil {
+			return nil, errors.New("unable to paginate on unknown column: %s", by.Column)
+		}
+
+		keys = append(keys, key{descending: by.Descending, field: field})
+		ordered = ordered || field == primary
+	}
+
+	if !ordered {
+		if primary == nil {
+			return nil, errors.New("model must have a primary key to use keyset pagination")
+		}
+
+		keys = append(keys, key{descending: false, field: primary})
+	}
+
+	return keys, nil
+}
+
+// column the column for a key.
+func (k key) column() clause.Column {
+	return clause.Column{Alias: "", Name: k.field.DBName, Raw: false, Table: clause.CurrentTable}
+}
+
+// decode a cursor into a condition which seeks past the encoded record.
+func (k keyset) decode(cursor string) (clause.Expression, error) {
+	contents, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	raw := make([]json.RawMessage, 0, len(k))
+
+	err = json.Unmarshal(contents, &raw)
+	if err != nil || len(raw) != len(k) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]any, len(k))
+
+	for index, current := range k {
+		value := reflect.New(current.field.FieldType)
+
+		err = json.Unmarshal(raw[index], value.Interface())
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+
+		values[index] = value.Elem().Interface()
+	}
+
+	return k.seek(values), nil
+}
+
+// encode the key values for model into a cursor.
+func (k keyset) encode(ctx context.Context, model any) (string, error) {
+	contents, err := json.Marshal(k.values(ctx, model))
+	if err != nil {
+		return "", errors.Wrap(err, "unable to encode pagination cursor")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(contents), nil
+}
+
+// order add ordering for keys which aren't already ordered by the query.
+func (k keyset) order(query *gorm.DB, ordered int) *gorm.DB {
+	for _, current := range k[ordered:] {
+		query = query.Order(clause.OrderByColumn{Column: current.column(), Desc: current.descending, Reorder: false})
+	}
+
+	return query
+}
+
+// seek build a condition which matches records after the key values.
+func (k keyset) seek(values []any) clause.Expression {
+	// Seek using (a > ?) OR (a = ? AND b > ?) so mixed directions are supported
+	conditions := make([]clause.Expression, 0, len(k))
+
+	for index, current := range k {
+		expressions := make([]clause.Expression, 0, index+1)
+
+		for previous := range index {
+			expressions = append(expressions, clause.Eq{Column: k[previous].column(), Value: values[previous]})
+		}
+
+		if current.descending {
+			expressions = append(expressions, clause.Lt{Column: current.column(), Value: values[index]})
+		} else {
+			expressions = append(expressions, clause.Gt{Column: current.column(), Value: values[index]})
+		}
+
+		conditions = append(conditions, clause.And(expressions...))
+	}
+
+	return clause.Or(conditions...)
+}
+
+// values read the key values from model.
+func (k keyset) values(ctx context.Context, model any) []any {
+	values := make([]any, 0, len(k))
+	reflected := reflect.Indirect(reflect.ValueOf(model))
+
+	for _, current := range k {
+		value, _ := current.field.ValueOf(ctx, reflected)
+		values = append(values, value)
+	}
+
+	return values
+}
@@ -0,0 +1,206 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+func TestRepository_Cursor(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 5))
+
+	page, err := instance.Cursor("", 2)
+	require.NoError(t, err)
+
+	assert.True(t, page.HasNext)
+	assert.Equal(t, []int{1, 2}, ids(page.Items))
+
+	page, err = instance.Cursor(page.Next, 2)
+	require.NoError(t, err)
+
+	assert.True(t, page.HasNext)
+	assert.Equal(t, []int{3, 4}, ids(page.Items))
+
+	page, err = instance.Cursor(page.Next, 2)
+	require.NoError(t, err)
+
+	assert.False(t, page.HasNext)
+	assert.Empty(t, page.Next)
+	assert.Equal(t, []int{5}, ids(page.Items))
+}
+
+func TestRepository_Cursor_OrderBy(t *testing.T) {
+	t.Parallel()
+
+	// Odd ids are true, order by test descending then id will return odd ids first
+	instance := database.Repository[modelmock.ModelMock](seed(t, 5)).
+		OrderBy(database.Order{Column: "model_mocks.test", Descending: true})
+
+	expected := [][]int{{1, 3}, {5, 2}, {4}}
+	cursor := ""
+
+	for _, items := range expected {
+		page, err := instance.Cursor(cursor, 2)
+		require.NoError(t, err)
+
+		assert.Equal(t, items, ids(page.Items))
+
+		cursor = page.Next
+	}
+
+	assert.Empty(t, cursor)
+}
+
+func TestRepository_Cursor_Where(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 5)).
+		OrderBy(database.Order{Column: "id", Descending: true})
+
+	page, err := instance.Cursor("", 1, database.Raw{Query: "test = ?", Parameters: []any{false}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{4}, ids(page.Items))
+
+	page, err = instance.Cursor(page.Next, 1, database.Raw{Query: "test = ?", Parameters: []any{false}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{2}, ids(page.Items))
+	assert.False(t, page.HasNext)
+}
+
+func TestRepository_Cursor_Errors(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 1))
+
+	testcases := map[string]struct {
+		cursor   string
+		expected string
+		instance database.Persister[modelmock.ModelMock]
+		size     int
+	}{
+		"invalid base64": {
+			cursor:   "!",
+			expected: "invalid pagination cursor",
+			instance: instance,
+			size:     1,
+		},
+		"invalid json": {
+			cursor:   "bm90IGpzb24",
+			expected: "invalid pagination cursor",
+			instance: instance,
+			size:     1,
+		},
+		"invalid value": {
+			cursor:   "WyJvbmUiXQ",
+			expected: "invalid pagination cursor",
+			instance: instance,
+			size:     1,
+		},
+		"invalid size": {
+			expected: "page size must be greater than zero",
+			instance: instance,
+			size:     0,
+		},
+		"unknown column": {
+			expected: "unable to paginate on unknown column: unknown",
+			instance: instance.OrderBy(database.Order{Column: "unknown"}),
+			size:     1,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			page, err := testcase.instance.Cursor(testcase.cursor, testcase.size)
+			require.Error(t, err)
+
+			require.EqualError(t, err, testcase.expected)
+			assert.Empty(t, page.Items)
+		})
+	}
+}
+
+func TestRepository_Paginate(t *testing.T) {
+	t.Parallel()
+
+	instance := database.Repository[modelmock.ModelMock](seed(t, 5)).OrderBy(database.Order{Column: "id"})
+
+	page, err := instance.Paginate(2, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{3, 4}, ids(page.Items))
+	assert.True(t, page.HasNext)
+	assert.Equal(t, 2, page.Page)
+	assert.Equal(t, 3, page.Pages)
+	assert.Equal(t, 2, page.Size)
+	assert.Equal(t, int64(5), page.Total)
+
+	page, err = instance.Paginate(3, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{5}, ids(page.Items))
+	assert.False(t, page.HasNext)
+
+	page, err = instance.Paginate(1, 2, &modelmock.ModelMock{Test: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{1, 3}, ids(page.Items))
+	assert.Equal(t, int64(3), page.Total)
+	assert.Equal(t, 2, page.Pages)
+}
+
+func TestRepository_Paginate_Empty(t *testing.T) {
+	t.Parallel()
+
+	page, err := database.Repository[modelmock.ModelMock](seed(t, 0)).Paginate(1, 10)
+	require.NoError(t, err)
+
+	assert.Empty(t, page.Items)
+	assert.False(t, page.HasNext)
+	assert.Equal(t, 0, page.Pages)
+	assert.Equal(t, int64(0), page.Total)
+}
+
+func TestRepository_Paginate_ErrInvalidPage(t *testing.T) {
+	t.Parallel()
+
+	page, err := database.Repository[modelmock.ModelMock](seed(t, 0)).Paginate(0, 10)
+	require.Error(t, err)
+
+	require.EqualError(t, err, "page and page size must be greater than zero")
+	assert.Empty(t, page.Items)
+}
+
+// ids extract the ids from a list of models.
+func ids(models []modelmock.ModelMock) []int {
+	result := make([]int, 0, len(models))
+
+	for _, model := range models {
+		result = append(result, model.ID)
+	}
+
+	return result
+}
+
+// seed create a database containing count models, odd ids are true.
+func seed(t *testing.T, count int) *database.Database {
+	t.Helper()
+
+	connection := connectFile(t)
+
+	for index := range count {
+		err := database.Repository[modelmock.ModelMock](connection).Create(&modelmock.ModelMock{Test: index%2 == 0})
+		require.NoError(t, err)
+	}
+
+	return connection
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	stdio "io"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/io"
+)
+
+// Plan statements which would be executed to migrate the database schema.
+type Plan struct {
+	// Driver name of the driver the statements were generated for
+	Driver string
+	// Statements in the order they would be executed
+	Statements []string
+}
+
+// recorder connection pool which records statements instead of executing them, queries are passed through so the
+// live schema can be inspected.
+type recorder struct {
+	dialector  gorm.Dialector
+	pool       gorm.ConnPool
+	statements *[]string
+}
+
+// Plan compare models with the live schema and return the statements Migrate would execute without applying them.
+// Statements are generated against the current schema, so changes which depend on earlier statements in the same
+// plan, such as SQLite table rebuilds, may differ slightly from what Migrate executes.
+func (d *Database) Plan(ctx context.Context, model ...any) (*Plan, error) {
+	statements := make([]string, 0)
+
+	orm := d.migrator(ctx).Session(&gorm.Session{SkipDefaultTransaction: true}) //nolint:exhaustruct // Only skip transactions
+	orm.Statement.ConnPool = recorder{dialector: orm.Dialector, pool: orm.Statement.ConnPool, statements: &statements}
+
+	err := orm.AutoMigrate(model...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to plan database migrations")
+	}
+
+	return &Plan{
+		Driver:     orm.Name(),
+		Statements: statements,
+	}, nil
+}
+
+// Save write the plan as SQL to filename, e.g. so the plan can be reviewed before migrating.
+func (p *Plan) Save(writer io.Writer, filename string) error {
+	err := writer.Write(filename, []byte(p.String()))
+	if err != nil {
+		return errors.Wrap(err, "unable to save plan to %s", filename)
+	}
+
+	return nil
+}
+
+// String return the plan as SQL.
+func (p *Plan) String() string {
+	var builder strings.Builder
+
+	_, _ = p.WriteTo(&builder)
+
+	return builder.String()
+}
+
+// WriteTo write the plan as SQL to a standard library writer, each statement is terminated and written on its own
+// line. Plans implement io.WriterTo from the standard library, use Save to write a plan with this package's io.Writer.
+func (p *Plan) WriteTo(writer stdio.Writer) (int64, error) {
+	var written int64
+
+	for _, statement := range p.Statements {
+		count, err := fmt.Fprintf(writer, "%s;\n", statement)
+		written += int64(count)
+
+		if err != nil {
+			return written, errors.Wrap(err, "unable to write plan")
+		}
+	}
+
+	return written, nil
+}
+
+// BeginTx start a transaction, transactions are ignored as nothing is executed.
+func (r recorder) BeginTx(_ context.Context, _ *sql.TxOptions) (gorm.ConnPool, error) {
+	return r, nil
+}
+
+// Commit the transaction.
+func (r recorder) Commit() error {
+	return nil
+}
+
+// ExecContext record a statement.
+func (r recorder) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err() //nolint:wrapcheck // Errors are handled by gorm
+	}
+
+	// Transactions are ignored, so savepoints gorm creates for migrations which use a transaction aren't planned
+	if savepoint(query) {
+		return driver.RowsAffected(0), nil
+	}
+
+	statement := query
+	if len(args) > 0 {
+		statement = r.dialector.Explain(query, args...)
+	}
+
+	*r.statements = append(*r.statements, strings.TrimSuffix(strings.TrimSpace(statement), ";"))
+
+	return driver.RowsAffected(0), nil
+}
+
+// PrepareContext prepare a statement on the underlying pool.
+func (r recorder) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.pool.PrepareContext(ctx, query) //nolint:wrapcheck // Errors are handled by gorm
+}
+
+// QueryContext run a query on the underlying pool.
+func (r recorder) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return r.pool.QueryContext(ctx, query, args...) //nolint:wrapcheck // Errors are handled by gorm
+}
+
+// QueryRowContext run a query on the underlying pool.
+func (r recorder) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return r.pool.QueryRowContext(ctx, query, args...)
+}
+
+// Rollback the transaction.
+func (r recorder) Rollback() error {
+	return nil
+}
+
+// savepoint determine if a statement creates, releases or rolls back to a savepoint.
+func savepoint(statement string) bool {
+	statement = strings.ToUpper(strings.TrimSpace(statement))
+
+	for _, prefix := range []string{"SAVEPOINT ", "RELEASE SAVEPOINT ", "ROLLBACK TO SAVEPOINT "} {
+		if strings.HasPrefix(statement, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
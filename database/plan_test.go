@@ -0,0 +1,189 @@
+package database_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+	"github.com/sjdaws/pkg/testing/io/readwritermock"
+)
+
+// extendedModelMock model mock with an additional column.
+type extendedModelMock struct {
+	ID        int
+	DeletedAt *gorm.DeletedAt `sql:"index"`
+	Test      bool
+	Extra     string
+}
+
+// failingWriter writer which always fails.
+type failingWriter struct{}
+
+// numberedMock model with an integer title.
+type numberedMock struct {
+	ID    int
+	Title int
+}
+
+// titledMock model with a text title in the same table as numberedMock, changing the type rebuilds sqlite tables.
+type titledMock struct {
+	ID    int
+	Title string
+}
+
+// TableName return the database table for this model.
+func (n numberedMock) TableName() string {
+	return "titles"
+}
+
+// TableName return the database table for this model.
+func (t titledMock) TableName() string {
+	return "titles"
+}
+
+// TableName return the database table for this model.
+func (m extendedModelMock) TableName() string {
+	return "model_mocks"
+}
+
+// Write fail to write.
+func (w failingWriter) Write(_ []byte) (int, error) {
+	return 0, errors.New("test")
+}
+
+func TestConnection_Plan(t *testing.T) {
+	t.Parallel()
+
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: t.TempDir() + "/test.db"})
+	require.NoError(t, err)
+
+	plan, err := connection.Plan(t.Context(), modelmock.ModelMock{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "sqlite", plan.Driver)
+	assert.Equal(t, []string{
+		"CREATE TABLE `model_mocks` (`id` integer PRIMARY KEY AUTOINCREMENT,`deleted_at` datetime,`test` numeric)",
+	}, plan.Statements)
+
+	// Nothing is applied
+	assert.False(t, connection.ORM().Migrator().HasTable("model_mocks"))
+
+	require.NoError(t, connection.Migrate(modelmock.ModelMock{}))
+
+	plan, err = connection.Plan(t.Context(), modelmock.ModelMock{})
+	require.NoError(t, err)
+
+	assert.Empty(t, plan.Statements)
+	assert.Empty(t, plan.String())
+
+	plan, err = connection.Plan(t.Context(), extendedModelMock{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"ALTER TABLE `model_mocks` ADD `extra` text"}, plan.Statements)
+	assert.False(t, connection.ORM().Migrator().HasColumn(&extendedModelMock{}, "extra"))
+}
+
+func TestConnection_Plan_Rebuild(t *testing.T) {
+	t.Parallel()
+
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: t.TempDir() + "/test.db"})
+	require.NoError(t, err)
+
+	require.NoError(t, connection.Migrate(numberedMock{}))
+
+	// Table rebuilds run in a transaction, the savepoint gorm creates isn't part of the plan
+	plan, err := connection.Plan(t.Context(), titledMock{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"PRAGMA foreign_keys = OFF",
+		"CREATE TABLE `titles__temp`  (`id` integer PRIMARY KEY AUTOINCREMENT,`title` text)",
+		"INSERT INTO `titles__temp`(`id`,`title`) SELECT `id`,`title` FROM `titles`",
+		"DROP TABLE `titles`",
+		"ALTER TABLE `titles__temp` RENAME TO `titles`",
+		"PRAGMA foreign_keys = ON",
+	}, plan.Statements)
+
+	// The plan can be run as is
+	for _, statement := range plan.Statements {
+		require.NoError(t, connection.ORM().Exec(statement).Error)
+	}
+
+	columns, err := connection.ORM().Migrator().ColumnTypes(&titledMock{})
+	require.NoError(t, err)
+
+	for _, column := range columns {
+		if column.Name() == "title" {
+			assert.Equal(t, "text", column.DatabaseTypeName())
+		}
+	}
+}
+
+func TestConnection_Plan_Error(t *testing.T) {
+	t.Parallel()
+
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: ":memory:"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	plan, err := connection.Plan(ctx, modelmock.ModelMock{})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to plan database migrations: context canceled")
+	assert.Nil(t, plan)
+}
+
+func TestPlan_Save(t *testing.T) {
+	t.Parallel()
+
+	plan := database.Plan{Driver: "sqlite", Statements: []string{"CREATE TABLE a (id int)", "DROP TABLE b"}}
+	writer := readwritermock.New(afero.NewMemMapFs())
+
+	require.NoError(t, plan.Save(writer, "plan.sql"))
+
+	contents, err := writer.Read("plan.sql")
+	require.NoError(t, err)
+
+	assert.Equal(t, "CREATE TABLE a (id int);\nDROP TABLE b;\n", string(contents))
+
+	writer.WriteError = errors.New("test")
+
+	err = plan.Save(writer, "plan.sql")
+	require.EqualError(t, err, "unable to save plan to plan.sql: test")
+}
+
+func TestPlan_WriteTo(t *testing.T) {
+	t.Parallel()
+
+	plan := database.Plan{Driver: "sqlite", Statements: []string{"CREATE TABLE a (id int)", "DROP TABLE b"}}
+
+	assert.Implements(t, (*io.WriterTo)(nil), &plan)
+
+	var buffer bytes.Buffer
+
+	written, err := plan.WriteTo(&buffer)
+	require.NoError(t, err)
+
+	expected := "CREATE TABLE a (id int);\nDROP TABLE b;\n"
+
+	assert.Equal(t, expected, buffer.String())
+	assert.Equal(t, int64(len(expected)), written)
+	assert.Equal(t, expected, plan.String())
+
+	written, err = plan.WriteTo(failingWriter{})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to write plan: test")
+	assert.Equal(t, int64(0), written)
+}
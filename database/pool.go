@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// Pool connection pool options, zero values keep the database/sql defaults.
+type Pool struct {
+	MaxIdle     int
+	MaxIdleTime time.Duration
+	MaxLifetime time.Duration
+	MaxOpen     int
+}
+
+// Close every connection to the database and replicas.
+func (d *Database) Close() error {
+	var failure error
+
+	for index, replica := range d.replicas {
+		err := closeConnection(replica)
+		if err != nil && failure == nil {
+			failure = errors.Wrap(err, "unable to close replica %d", index)
+		}
+	}
+
+	err := closeConnection(d.orm)
+	if err != nil && failure == nil {
+		failure = errors.Wrap(err, "unable to close database")
+	}
+
+	return failure
+}
+
+// Ping verify the database and replicas can be reached.
+func (d *Database) Ping(ctx context.Context) error {
+	pool, err := d.orm.DB()
+	if err != nil {
+		return errors.Wrap(err, "unable to ping database")
+	}
+
+	err = pool.PingContext(ctx)
+	if err != nil {
+		return errors.Wrap(translate(err), "unable to ping database")
+	}
+
+	for index, replica := range d.replicas {
+		pool, err = replica.DB()
+		if err == nil {
+			err = pool.PingContext(ctx)
+		}
+
+		if err != nil {
+			return errors.Wrap(translate(err), "unable to ping replica %d", index)
+		}
+	}
+
+	return nil
+}
+
+// Stats return connection pool statistics for the primary database.
+func (d *Database) Stats() sql.DBStats {
+	pool, err := d.orm.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+
+	return pool.Stats()
+}
+
+// configure apply pool options to a connection.
+func (p Pool) configure(orm *gorm.DB) error {
+	pool, err := orm.DB()
+	if err != nil {
+		return errors.Wrap(err, "unable to configure connection pool")
+	}
+
+	if p.MaxIdle != 0 {
+		pool.SetMaxIdleConns(p.MaxIdle)
+	}
+
+	if p.MaxIdleTime != 0 {
+		pool.SetConnMaxIdleTime(p.MaxIdleTime)
+	}
+
+	if p.MaxLifetime != 0 {
+		pool.SetConnMaxLifetime(p.MaxLifetime)
+	}
+
+	if p.MaxOpen != 0 {
+		pool.SetMaxOpenConns(p.MaxOpen)
+	}
+
+	return nil
+}
+
+// closeConnection close the pool behind a connection.
+func closeConnection(orm *gorm.DB) error {
+	pool, err := orm.DB()
+	if err != nil {
+		return err //nolint:wrapcheck // Wrapped by caller
+	}
+
+	return pool.Close() //nolint:wrapcheck // Wrapped by caller
+}
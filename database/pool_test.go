@@ -0,0 +1,90 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+)
+
+func TestConnection_Close(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+
+	connection, err := database.Connect(database.Config{
+		Driver:   "sqlite",
+		Name:     directory + "/primary.db",
+		Replicas: []database.Config{{Name: directory + "/replica.db"}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, connection.Ping(t.Context()))
+	require.NoError(t, connection.Close())
+
+	err = connection.Ping(t.Context())
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to ping database: sql: database is closed")
+}
+
+func TestConnection_Ping_ErrCancelled(t *testing.T) {
+	t.Parallel()
+
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: ":memory:"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	err = connection.Ping(ctx)
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to ping database: context canceled")
+}
+
+func TestConnection_Stats(t *testing.T) {
+	t.Parallel()
+
+	connection, err := database.Connect(database.Config{
+		Driver: "sqlite",
+		Name:   t.TempDir() + "/test.db",
+		Pool: database.Pool{
+			MaxIdle:     1,
+			MaxIdleTime: time.Minute,
+			MaxLifetime: time.Hour,
+			MaxOpen:     3,
+		},
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, connection.Close())
+	})
+
+	require.NoError(t, connection.Ping(t.Context()))
+
+	stats := connection.Stats()
+
+	assert.Equal(t, 3, stats.MaxOpenConnections)
+	assert.Equal(t, 1, stats.OpenConnections)
+}
+
+func TestConnection_Stats_Transaction(t *testing.T) {
+	t.Parallel()
+
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Name: ":memory:", Pool: database.Pool{MaxOpen: 2}})
+	require.NoError(t, err)
+
+	err = connection.WithTransaction(t.Context(), func(tx database.Connection) error {
+		assert.Equal(t, 2, tx.Stats().MaxOpenConnections)
+		assert.Equal(t, 1, tx.Stats().InUse)
+
+		return tx.Ping(t.Context())
+	})
+	require.NoError(t, err)
+}
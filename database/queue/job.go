@@ -0,0 +1,58 @@
+package queue
+
+import (
+	"time"
+)
+
+// Job a unit of work stored in the queue_jobs table.
+type Job struct {
+	// Attempts number of times the job has been claimed by a worker, including the current attempt
+	Attempts  int
+	CreatedAt time.Time
+	ID        int64 `gorm:"primaryKey"`
+	// LastError message from the most recent failed attempt
+	LastError string
+	// LockedUntil time a running job becomes visible to other workers again if it hasn't finished
+	LockedUntil *time.Time
+	MaxAttempts int
+	Payload     []byte
+	// Priority jobs with a higher priority are run first
+	Priority int
+	Queue    string    `gorm:"size:191;index:idx_queue_jobs_poll,priority:1"`
+	RunAt    time.Time `gorm:"index:idx_queue_jobs_poll,priority:3"`
+	State    State     `gorm:"size:16;index:idx_queue_jobs_poll,priority:2"`
+	// UniqueKey only one job with a key can exist until it succeeds, a random key is used if none is provided
+	UniqueKey string `gorm:"size:191;uniqueIndex"`
+	UpdatedAt time.Time
+}
+
+// JobOptions options used when enqueueing a job.
+type JobOptions struct {
+	// MaxAttempts number of attempts before the job is dead, the queue default is used if zero
+	MaxAttempts int
+	// Priority jobs with a higher priority are run first
+	Priority int
+	// RunAt earliest time the job can run, the job can run immediately if zero
+	RunAt time.Time
+	// UniqueKey prevents another job with the same key being enqueued until the job succeeds
+	UniqueKey string
+}
+
+// State of a job.
+type State string
+
+const (
+	// StateDead the job failed on every attempt and won't be run again unless it's requeued.
+	StateDead State = "dead"
+
+	// StatePending the job is waiting to run.
+	StatePending State = "pending"
+
+	// StateRunning the job has been claimed by a worker.
+	StateRunning State = "running"
+)
+
+// TableName return the database table for jobs.
+func (j Job) TableName() string {
+	return "queue_jobs"
+}
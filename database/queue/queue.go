@@ -0,0 +1,245 @@
+package queue
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/logging"
+	"github.com/sjdaws/pkg/uuid"
+)
+
+// Handler function which processes a job, returning an error will retry the job until it runs out of attempts.
+type Handler func(ctx context.Context, job Job) error
+
+// Options used to configure a queue, zero values use defaults.
+type Options struct {
+	// Backoff delay before a failed job is retried, attempt starts at 1. Defaults to 10 seconds doubling with each
+	// attempt up to an hour
+	Backoff func(attempt int) time.Duration
+	// Concurrency maximum number of jobs run at the same time, defaults to 1
+	Concurrency int
+	// Logger destination for worker logs, logging.Default is used if nil
+	Logger logging.Logger
+	// MaxAttempts default number of attempts before a job is dead, defaults to 5
+	MaxAttempts int
+	// PollInterval time to wait between checking for jobs when the queue is empty, defaults to 1 second
+	PollInterval time.Duration
+	// VisibilityTimeout time a job is hidden from other workers once claimed, the timeout is extended while the
+	// handler is running so a job is only run again if its worker stops. Defaults to 5 minutes
+	VisibilityTimeout time.Duration
+}
+
+// Queue database backed job queue. Jobs are claimed using SKIP LOCKED, or READPAST on SQL Server, so workers in
+// multiple processes don't block each other. SQLite serialises writers, so a busy timeout should be configured when
+// more than one worker shares a database file.
+type Queue struct {
+	connection database.Connection
+	handlers   map[string]Handler
+	logger     logging.Logger
+	mutex      *sync.Mutex
+	options    Options
+}
+
+// Defaults used when options aren't provided.
+const (
+	defaultBackoff           = 10 * time.Second
+	defaultConcurrency       = 1
+	defaultMaxAttempts       = 5
+	defaultMaxBackoff        = time.Hour
+	defaultPollInterval      = time.Second
+	defaultVisibilityTimeout = 5 * time.Minute
+)
+
+// ErrDuplicate error returned when a job with the same unique key already exists.
+var ErrDuplicate = errors.New("a job with the unique key already exists")
+
+// New create a queue, the jobs table is created if it doesn't exist.
+func New(connection database.Connection, options Options) (*Queue, error) {
+	if connection == nil {
+		return nil, errors.New("connection must be provided")
+	}
+
+	err := connection.Migrate(&Job{})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create jobs table")
+	}
+
+	if options.Backoff == nil {
+		options.Backoff = backoff
+	}
+
+	if options.Concurrency < 1 {
+		options.Concurrency = defaultConcurrency
+	}
+
+	if options.MaxAttempts < 1 {
+		options.MaxAttempts = defaultMaxAttempts
+	}
+
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaultPollInterval
+	}
+
+	if options.VisibilityTimeout <= 0 {
+		options.VisibilityTimeout = defaultVisibilityTimeout
+	}
+
+	logger := options.Logger
+	if logger == nil {
+		logger = logging.Default()
+	}
+
+	return &Queue{
+		connection: connection,
+		handlers:   make(map[string]Handler),
+		logger:     logger,
+		mutex:      &sync.Mutex{},
+		options:    options,
+	}, nil
+}
+
+// Dead return jobs in a queue which failed on every attempt.
+func (q *Queue) Dead(ctx context.Context, queue string) ([]Job, error) {
+	jobs, err := database.Repository[Job](q.connection).
+		WithContext(ctx).
+		OrderBy(database.Order{Column: "id", Descending: false}).
+		Get(database.Eq("queue", queue), database.Eq("state", StateDead))
+	if err != nil {
+		if errors.Is(err, database.ErrNoResults) {
+			return []Job{}, nil
+		}
+
+		return nil, errors.Wrap(err, "unable to fetch dead jobs")
+	}
+
+	return jobs, nil
+}
+
+// Enqueue add a job to a queue.
+func (q *Queue) Enqueue(ctx context.Context, queue string, payload []byte, options ...JobOptions) (*Job, error) {
+	var option JobOptions
+
+	if len(options) > 0 {
+		option = options[0]
+	}
+
+	now := time.Now().UTC()
+
+	job := Job{
+		Attempts:    0,
+		CreatedAt:   now,
+		ID:          0,
+		LastError:   "",
+		LockedUntil: nil,
+		MaxAttempts: option.MaxAttempts,
+		Payload:     payload,
+		Priority:    option.Priority,
+		Queue:       queue,
+		RunAt:       option.RunAt.UTC(),
+		State:       StatePending,
+		UniqueKey:   option.UniqueKey,
+		UpdatedAt:   now,
+	}
+
+	if job.MaxAttempts < 1 {
+		job.MaxAttempts = q.options.MaxAttempts
+	}
+
+	if option.RunAt.IsZero() {
+		job.RunAt = now
+	}
+
+	if job.UniqueKey == "" {
+		job.UniqueKey = uuid.New().String()
+	}
+
+	// The insert is skipped rather than failing if the unique key exists so duplicates are detected without relying on
+	// how the driver reports duplicate keys
+	conflict := clause.OnConflict{
+		Columns:      []clause.Column{{Alias: "", Name: "unique_key", Raw: false, Table: ""}},
+		Where:        clause.Where{Exprs: nil},
+		TargetWhere:  clause.Where{Exprs: nil},
+		OnConstraint: "",
+		DoNothing:    true,
+		DoUpdates:    nil,
+		UpdateAll:    false,
+	}
+
+	result := q.connection.ORM().WithContext(ctx).Clauses(conflict).Create(&job)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "unable to enqueue job")
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, ErrDuplicate
+	}
+
+	return &job, nil
+}
+
+// Handle register the handler for a queue, a worker only claims jobs from queues with a handler.
+func (q *Queue) Handle(queue string, handler Handler) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.handlers[queue] = handler
+}
+
+// Requeue move a dead job back to pending so it's run again with a fresh set of attempts.
+func (q *Queue) Requeue(ctx context.Context, id int64) error {
+	updated, err := database.Repository[Job](q.connection).WithContext(ctx).UpdateWhere(
+		map[string]any{"attempts": 0, "last_error": "", "run_at": time.Now().UTC(), "state": StatePending},
+		database.Eq("id", id),
+		database.Eq("state", StateDead),
+	)
+	if err != nil {
+		return errors.Wrap(err, "unable to requeue job %d", id)
+	}
+
+	if updated == 0 {
+		return errors.New("job %d isn't dead", id)
+	}
+
+	return nil
+}
+
+// handler find the handler for a queue.
+func (q *Queue) handler(queue string) (Handler, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	handler, ok := q.handlers[queue]
+
+	return handler, ok
+}
+
+// queues names of queues with a handler.
+func (q *Queue) queues() []string {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	names := make([]string, 0, len(q.handlers))
+	for name := range q.handlers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// backoff default delay before a failed job is retried.
+func backoff(attempt int) time.Duration {
+	delay := defaultBackoff << max(attempt-1, 0)
+	if delay <= 0 || delay > defaultMaxBackoff {
+		return defaultMaxBackoff
+	}
+
+	return delay
+}
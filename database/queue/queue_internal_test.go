@@ -0,0 +1,42 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		attempt  int
+		expected time.Duration
+	}{
+		"capped": {
+			attempt:  10,
+			expected: time.Hour,
+		},
+		"first attempt": {
+			attempt:  1,
+			expected: 10 * time.Second,
+		},
+		"overflow": {
+			attempt:  100,
+			expected: time.Hour,
+		},
+		"third attempt": {
+			attempt:  3,
+			expected: 40 * time.Second,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testcase.expected, backoff(testcase.attempt))
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package queue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/database/queue"
+	"github.com/sjdaws/pkg/testing/database/connectionmock"
+	"github.com/sjdaws/pkg/testing/logging/logmock"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	instance, err := queue.New(connect(t), queue.Options{Logger: logmock.New()})
+	require.NoError(t, err)
+
+	assert.NotNil(t, instance)
+}
+
+func TestNew_Errors(t *testing.T) {
+	t.Parallel()
+
+	_, err := queue.New(nil, queue.Options{})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "connection must be provided")
+
+	_, err = queue.New(connectionmock.New(t, connectionmock.Options{AlwaysFail: true}), queue.Options{})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to create jobs table: migration failed")
+}
+
+func TestQueue_Enqueue(t *testing.T) {
+	t.Parallel()
+
+	instance, err := queue.New(connect(t), queue.Options{Logger: logmock.New(), MaxAttempts: 3})
+	require.NoError(t, err)
+
+	before := time.Now().UTC()
+
+	job, err := instance.Enqueue(context.Background(), "email", []byte("payload"))
+	require.NoError(t, err)
+
+	assert.Positive(t, job.ID)
+	assert.Equal(t, 0, job.Attempts)
+	assert.Equal(t, 3, job.MaxAttempts)
+	assert.Equal(t, []byte("payload"), job.Payload)
+	assert.Equal(t, 0, job.Priority)
+	assert.Equal(t, "email", job.Queue)
+	assert.False(t, job.RunAt.Before(before))
+	assert.Equal(t, queue.StatePending, job.State)
+	assert.NotEmpty(t, job.UniqueKey)
+
+	runAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	job, err = instance.Enqueue(context.Background(), "email", nil, queue.JobOptions{
+		MaxAttempts: 7,
+		Priority:    10,
+		RunAt:       runAt,
+		UniqueKey:   "welcome:1",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, job.MaxAttempts)
+	assert.Equal(t, 10, job.Priority)
+	assert.Equal(t, runAt, job.RunAt)
+	assert.Equal(t, "welcome:1", job.UniqueKey)
+}
+
+func TestQueue_Enqueue_Errors(t *testing.T) {
+	t.Parallel()
+
+	connection := connect(t)
+
+	instance, err := queue.New(connection, queue.Options{Logger: logmock.New()})
+	require.NoError(t, err)
+
+	_, err = instance.Enqueue(context.Background(), "email", nil, queue.JobOptions{UniqueKey: "welcome:1"})
+	require.NoError(t, err)
+
+	_, err = instance.Enqueue(context.Background(), "email", nil, queue.JobOptions{UniqueKey: "welcome:1"})
+	require.ErrorIs(t, err, queue.ErrDuplicate)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = instance.Enqueue(ctx, "email", nil)
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "unable to enqueue job")
+}
+
+func TestQueue_Enqueue_Connection(t *testing.T) {
+	t.Parallel()
+
+	// Connections which don't translate driver errors still detect duplicates
+	connection := connectionmock.New(t, connectionmock.Options{FileBased: true})
+
+	instance, err := queue.New(connection, queue.Options{Logger: logmock.New()})
+	require.NoError(t, err)
+
+	job, err := instance.Enqueue(t.Context(), "email", nil, queue.JobOptions{UniqueKey: "welcome:1"})
+	require.NoError(t, err)
+
+	assert.NotZero(t, job.ID)
+
+	job, err = instance.Enqueue(t.Context(), "email", nil, queue.JobOptions{UniqueKey: "welcome:1"})
+	require.ErrorIs(t, err, queue.ErrDuplicate)
+
+	assert.Nil(t, job)
+}
+
+func TestQueue_Requeue(t *testing.T) {
+	t.Parallel()
+
+	connection := connect(t)
+
+	instance, err := queue.New(connection, queue.Options{Logger: logmock.New()})
+	require.NoError(t, err)
+
+	job, err := instance.Enqueue(context.Background(), "email", nil)
+	require.NoError(t, err)
+
+	err = instance.Requeue(context.Background(), job.ID)
+	require.Error(t, err)
+
+	require.EqualError(t, err, "job 1 isn't dead")
+
+	result := connection.ORM().Model(job).Updates(map[string]any{"attempts": 5, "state": queue.StateDead})
+	require.NoError(t, result.Error)
+
+	dead, err := instance.Dead(context.Background(), "email")
+	require.NoError(t, err)
+
+	require.Len(t, dead, 1)
+	assert.Equal(t, job.ID, dead[0].ID)
+
+	err = instance.Requeue(context.Background(), job.ID)
+	require.NoError(t, err)
+
+	dead, err = instance.Dead(context.Background(), "email")
+	require.NoError(t, err)
+
+	assert.Empty(t, dead)
+
+	requeued, err := database.Repository[queue.Job](connection).One(database.Eq("id", job.ID))
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, requeued.Attempts)
+	assert.Equal(t, queue.StatePending, requeued.State)
+}
+
+// connect create a database which can be shared by several connections.
+func connect(t *testing.T) *database.Database {
+	t.Helper()
+
+	connection, err := database.Connect(database.Config{
+		Driver: "sqlite",
+		Logger: logmock.New(),
+		Name:   t.TempDir() + "/queue.db?_pragma=busy_timeout(5000)",
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = connection.Close()
+	})
+
+	return connection
+}
@@ -0,0 +1,290 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/errors"
+)
+
+// errWorkerTimeout failure recorded for a job whose worker stopped responding on the final attempt.
+var errWorkerTimeout = errors.New("worker stopped responding before the job finished")
+
+// Run claim and process jobs until ctx is cancelled, waiting for running jobs to finish before returning. Handlers
+// receive ctx, so a handler which stops early when ctx is cancelled will be retried.
+func (q *Queue) Run(ctx context.Context) error {
+	queues := q.queues()
+	if len(queues) == 0 {
+		return errors.New("at least one handler must be registered")
+	}
+
+	var running sync.WaitGroup
+
+	defer running.Wait()
+
+	slots := make(chan struct{}, q.options.Concurrency)
+	released := make(chan struct{}, 1)
+
+	for ctx.Err() == nil {
+		free := cap(slots) - len(slots)
+		claimed := 0
+
+		if free > 0 {
+			jobs, err := q.claim(ctx, queues, free)
+			if err != nil && ctx.Err() == nil {
+				q.logger.Error(errors.Wrap(err, "unable to claim jobs"))
+			}
+
+			for _, job := range jobs {
+				slots <- struct{}{}
+
+				running.Add(1)
+
+				go func() {
+					defer func() {
+						<-slots
+
+						// Wake the poller so the free slot can be filled
+						select {
+						case released <- struct{}{}:
+						default:
+						}
+
+						running.Done()
+					}()
+
+					q.process(ctx, job)
+				}()
+			}
+
+			claimed = len(jobs)
+		}
+
+		// A full batch means more jobs may be waiting
+		if claimed > 0 && claimed == free {
+			continue
+		}
+
+		timer := time.NewTimer(q.options.PollInterval)
+
+		select {
+		case <-ctx.Done():
+		case <-released:
+		case <-timer.C:
+		}
+
+		timer.Stop()
+	}
+
+	return nil
+}
+
+// claim lock available jobs and mark them as running. Jobs are claimed with a conditional update on the attempt
+// count, so a job can't be claimed twice even if the driver doesn't support SKIP LOCKED. Jobs whose worker stopped
+// responding on their final attempt are marked as dead instead of being run again.
+func (q *Queue) claim(ctx context.Context, queues []string, limit int) ([]Job, error) {
+	claimed := make([]Job, 0, limit)
+
+	err := q.connection.WithTransaction(ctx, func(tx database.Connection) error {
+		claimed = claimed[:0]
+		now := time.Now().UTC()
+
+		jobs, err := database.Repository[Job](tx).
+			WithContext(ctx).
+			Lock(database.LockUpdate|database.LockSkipLocked).
+			OrderBy(
+				database.Order{Column: "priority", Descending: true},
+				database.Order{Column: "run_at", Descending: false},
+			).
+			Limit(limit).
+			Get(database.In("queue", queues), database.Or{
+				database.And(database.Eq("state", StatePending), database.Lte("run_at", now)),
+				database.And(database.Eq("state", StateRunning), database.Lte("locked_until", now)),
+			})
+		if err != nil {
+			if errors.Is(err, database.ErrNoResults) {
+				return nil
+			}
+
+			return err
+		}
+
+		repository := database.Repository[Job](tx).WithContext(ctx)
+
+		for _, job := range jobs {
+			if job.State == StateRunning && job.Attempts >= job.MaxAttempts {
+				err = q.expire(repository, job)
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			lockedUntil := now.Add(q.options.VisibilityTimeout)
+
+			updated, err := repository.UpdateWhere(
+				map[string]any{"attempts": job.Attempts + 1, "locked_until": lockedUntil, "state": StateRunning},
+				database.Eq("id", job.ID),
+				database.Eq("attempts", job.Attempts),
+			)
+			if err != nil {
+				return err
+			}
+
+			if updated == 0 {
+				continue
+			}
+
+			job.Attempts++
+			job.LockedUntil = &lockedUntil
+			job.State = StateRunning
+
+			claimed = append(claimed, job)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Wrapped by caller
+	}
+
+	return claimed, nil
+}
+
+// complete remove a job which succeeded.
+func (q *Queue) complete(ctx context.Context, job Job) error {
+	_, err := database.Repository[Job](q.connection).
+		WithContext(ctx).
+		DeleteWhere(database.Eq("id", job.ID), database.Eq("attempts", job.Attempts))
+	if err != nil {
+		return errors.Wrap(err, "unable to complete job %d", job.ID)
+	}
+
+	return nil
+}
+
+// fail record a failed attempt, the job is retried after a backoff or marked as dead once it runs out of attempts.
+func (q *Queue) fail(ctx context.Context, job Job, failure error) error {
+	values := map[string]any{
+		"last_error":   failure.Error(),
+		"locked_until": nil,
+		"run_at":       time.Now().UTC().Add(q.options.Backoff(job.Attempts)),
+		"state":        StatePending,
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		values["state"] = StateDead
+	}
+
+	_, err := database.Repository[Job](q.connection).
+		WithContext(ctx).
+		UpdateWhere(values, database.Eq("id", job.ID), database.Eq("attempts", job.Attempts))
+	if err != nil {
+		return errors.Wrap(err, "unable to record failure for job %d", job.ID)
+	}
+
+	if values["state"] == StateDead {
+		q.logger.Error("job %d on queue %s is dead after %d attempts: %v", job.ID, job.Queue, job.Attempts, failure)
+
+		return nil
+	}
+
+	q.logger.Warn(
+		"job %d on queue %s failed on attempt %d of %d: %v",
+		job.ID,
+		job.Queue,
+		job.Attempts,
+		job.MaxAttempts,
+		failure,
+	)
+
+	return nil
+}
+
+// expire mark a job as dead after its worker stopped responding on the final attempt.
+func (q *Queue) expire(repository database.Persister[Job], job Job) error {
+	updated, err := repository.UpdateWhere(
+		map[string]any{"last_error": errWorkerTimeout.Error(), "locked_until": nil, "state": StateDead},
+		database.Eq("id", job.ID),
+		database.Eq("attempts", job.Attempts),
+	)
+	if err != nil {
+		return errors.Wrap(err, "unable to expire job %d", job.ID)
+	}
+
+	if updated > 0 {
+		q.logger.Error("job %d on queue %s is dead after %d attempts: %v", job.ID, job.Queue, job.Attempts, errWorkerTimeout)
+	}
+
+	return nil
+}
+
+// heartbeat extend the visibility timeout of a running job until done is closed.
+func (q *Queue) heartbeat(ctx context.Context, job Job, done <-chan struct{}) {
+	ticker := time.NewTicker(q.options.VisibilityTimeout / 2) //nolint:mnd // Extend well before the timeout expires
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := database.Repository[Job](q.connection).WithContext(ctx).UpdateWhere(
+				map[string]any{"locked_until": time.Now().UTC().Add(q.options.VisibilityTimeout)},
+				database.Eq("id", job.ID),
+				database.Eq("attempts", job.Attempts),
+			)
+			if err != nil {
+				q.logger.Warn(errors.Wrap(err, "unable to extend visibility timeout for job %d", job.ID))
+			}
+		}
+	}
+}
+
+// process run the handler for a job and record the result.
+func (q *Queue) process(ctx context.Context, job Job) {
+	done := make(chan struct{})
+
+	go q.heartbeat(ctx, job, done)
+
+	failure := q.run(ctx, job)
+
+	close(done)
+
+	// Record the result even if the worker is stopping
+	ctx = context.WithoutCancel(ctx)
+
+	var err error
+
+	if failure == nil {
+		err = q.complete(ctx, job)
+	} else {
+		err = q.fail(ctx, job, failure)
+	}
+
+	if err != nil {
+		q.logger.Error(err)
+	}
+}
+
+// run the handler for a job, panics are returned as errors.
+func (q *Queue) run(ctx context.Context, job Job) (failure error) {
+	handler, ok := q.handler(job.Queue)
+	if !ok {
+		return errors.New("no handler registered for queue %s", job.Queue)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			failure = errors.New("job panicked: %s", fmt.Sprint(recovered))
+		}
+	}()
+
+	return handler(ctx, job)
+}
@@ -0,0 +1,345 @@
+package queue_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/database/queue"
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/testing/logging/logmock"
+)
+
+func TestQueue_Run(t *testing.T) {
+	t.Parallel()
+
+	connection := connect(t)
+
+	instance, err := queue.New(connection, queue.Options{Logger: logmock.New(), PollInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	var (
+		mutex     sync.Mutex
+		processed []string
+	)
+
+	instance.Handle("email", func(_ context.Context, job queue.Job) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		processed = append(processed, string(job.Payload))
+
+		return nil
+	})
+
+	ctx := context.Background()
+
+	_, err = instance.Enqueue(ctx, "email", []byte("low"), queue.JobOptions{Priority: 1})
+	require.NoError(t, err)
+
+	_, err = instance.Enqueue(ctx, "email", []byte("high"), queue.JobOptions{Priority: 5})
+	require.NoError(t, err)
+
+	_, err = instance.Enqueue(ctx, "email", []byte("later"), queue.JobOptions{RunAt: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	_, err = instance.Enqueue(ctx, "transcode", []byte("unhandled"))
+	require.NoError(t, err)
+
+	run(t, instance, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return len(processed) == 2
+	})
+
+	assert.Equal(t, []string{"high", "low"}, processed)
+
+	// Successful jobs are removed, their unique keys can be used again
+	count, err := database.Repository[queue.Job](connection).Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), count)
+}
+
+func TestQueue_Run_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	instance, err := queue.New(connect(t), queue.Options{
+		Concurrency:  2,
+		Logger:       logmock.New(),
+		PollInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	var (
+		mutex     sync.Mutex
+		active    int
+		completed int
+		peak      int
+	)
+
+	instance.Handle("transcode", func(_ context.Context, _ queue.Job) error {
+		mutex.Lock()
+		active++
+		peak = max(peak, active)
+		mutex.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mutex.Lock()
+		active--
+		completed++
+		mutex.Unlock()
+
+		return nil
+	})
+
+	for index := range 6 {
+		_, err = instance.Enqueue(context.Background(), "transcode", []byte(strconv.Itoa(index)))
+		require.NoError(t, err)
+	}
+
+	run(t, instance, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return completed == 6
+	})
+
+	assert.Equal(t, 2, peak)
+}
+
+func TestQueue_Run_Dead(t *testing.T) {
+	t.Parallel()
+
+	log := logmock.New()
+
+	instance, err := queue.New(connect(t), queue.Options{
+		Backoff:      func(_ int) time.Duration { return 0 },
+		Logger:       log,
+		MaxAttempts:  2,
+		PollInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	instance.Handle("email", func(_ context.Context, _ queue.Job) error {
+		panic("boom")
+	})
+
+	job, err := instance.Enqueue(context.Background(), "email", nil)
+	require.NoError(t, err)
+
+	run(t, instance, func() bool {
+		dead, err := instance.Dead(context.Background(), "email")
+		require.NoError(t, err)
+
+		return len(dead) == 1
+	})
+
+	dead, err := instance.Dead(context.Background(), "email")
+	require.NoError(t, err)
+
+	require.Len(t, dead, 1)
+	assert.Equal(t, job.ID, dead[0].ID)
+	assert.Equal(t, 2, dead[0].Attempts)
+	assert.Equal(t, "job panicked: boom", dead[0].LastError)
+	assert.Nil(t, dead[0].LockedUntil)
+
+	assert.Equal(t, []map[string]string{
+		{"warn": "job 1 on queue email failed on attempt 1 of 2: job panicked: boom"},
+		{"error": "job 1 on queue email is dead after 2 attempts: job panicked: boom"},
+	}, log.GetAllLogs())
+}
+
+func TestQueue_Run_Errors(t *testing.T) {
+	t.Parallel()
+
+	instance, err := queue.New(connect(t), queue.Options{Logger: logmock.New()})
+	require.NoError(t, err)
+
+	err = instance.Run(context.Background())
+	require.Error(t, err)
+
+	require.EqualError(t, err, "at least one handler must be registered")
+}
+
+func TestQueue_Run_Retry(t *testing.T) {
+	t.Parallel()
+
+	instance, err := queue.New(connect(t), queue.Options{
+		Backoff:      func(_ int) time.Duration { return 0 },
+		Logger:       logmock.New(),
+		PollInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	var (
+		mutex    sync.Mutex
+		attempts []int
+	)
+
+	instance.Handle("email", func(_ context.Context, job queue.Job) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		attempts = append(attempts, job.Attempts)
+
+		if job.Attempts < 3 {
+			return errors.New("smtp unavailable")
+		}
+
+		return nil
+	})
+
+	_, err = instance.Enqueue(context.Background(), "email", nil)
+	require.NoError(t, err)
+
+	run(t, instance, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return len(attempts) == 3
+	})
+
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+}
+
+func TestQueue_Run_DeadTimeout(t *testing.T) {
+	t.Parallel()
+
+	connection := connect(t)
+	log := logmock.New()
+
+	instance, err := queue.New(connection, queue.Options{
+		Logger:       log,
+		PollInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// A job whose worker stopped on the final attempt is dead rather than run again
+	expired := time.Now().UTC().Add(-time.Minute)
+
+	result := connection.ORM().Create(&queue.Job{
+		Attempts:    3,
+		LockedUntil: &expired,
+		MaxAttempts: 3,
+		Queue:       "transcode",
+		RunAt:       expired,
+		State:       queue.StateRunning,
+		UniqueKey:   "abandoned",
+	})
+	require.NoError(t, result.Error)
+
+	var ran atomic.Bool
+
+	instance.Handle("transcode", func(_ context.Context, _ queue.Job) error {
+		ran.Store(true)
+
+		return nil
+	})
+
+	run(t, instance, func() bool {
+		dead, err := instance.Dead(context.Background(), "transcode")
+		require.NoError(t, err)
+
+		return len(dead) == 1
+	})
+
+	dead, err := instance.Dead(context.Background(), "transcode")
+	require.NoError(t, err)
+
+	require.Len(t, dead, 1)
+	assert.False(t, ran.Load())
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Equal(t, "worker stopped responding before the job finished", dead[0].LastError)
+	assert.Nil(t, dead[0].LockedUntil)
+
+	assert.Equal(t, []map[string]string{
+		{"error": "job 1 on queue transcode is dead after 3 attempts: worker stopped responding before the job finished"},
+	}, log.GetAllLogs())
+}
+
+func TestQueue_Run_VisibilityTimeout(t *testing.T) {
+	t.Parallel()
+
+	connection := connect(t)
+
+	instance, err := queue.New(connection, queue.Options{
+		Logger:            logmock.New(),
+		PollInterval:      10 * time.Millisecond,
+		VisibilityTimeout: 40 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// A job claimed by a worker which stopped becomes visible once its lock expires
+	expired := time.Now().UTC().Add(-time.Minute)
+
+	result := connection.ORM().Create(&queue.Job{
+		Attempts:    1,
+		LockedUntil: &expired,
+		MaxAttempts: 5,
+		Queue:       "transcode",
+		RunAt:       expired,
+		State:       queue.StateRunning,
+		UniqueKey:   "abandoned",
+	})
+	require.NoError(t, result.Error)
+
+	var (
+		mutex    sync.Mutex
+		attempts []int
+		extended bool
+	)
+
+	instance.Handle("transcode", func(_ context.Context, job queue.Job) error {
+		// Running jobs have their lock extended so they aren't claimed again
+		time.Sleep(100 * time.Millisecond)
+
+		current, err := database.Repository[queue.Job](connection).One(database.Eq("id", job.ID))
+		require.NoError(t, err)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		attempts = append(attempts, job.Attempts)
+		extended = current.LockedUntil.After(*job.LockedUntil)
+
+		return nil
+	})
+
+	run(t, instance, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return len(attempts) > 0
+	})
+
+	assert.Equal(t, []int{2}, attempts)
+	assert.True(t, extended)
+}
+
+// run the queue until done returns true.
+func run(t *testing.T, instance *queue.Queue, done func() bool) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error)
+
+	go func() {
+		stopped <- instance.Run(ctx)
+	}()
+
+	assert.Eventually(t, done, 5*time.Second, 5*time.Millisecond)
+
+	cancel()
+
+	require.NoError(t, <-stopped)
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/sjdaws/pkg/database/drivers"
+)
+
+// DriverFactory create a driver from a configuration.
+type DriverFactory func(config Config) (Driver, error)
+
+// registry drivers available to Connect keyed by lower case name.
+//
+//nolint:gochecknoglobals // Registry must be global so drivers can be registered from anywhere
+var registry = struct {
+	factories map[string]DriverFactory
+	mutex     sync.RWMutex
+}{
+	factories: map[string]DriverFactory{
+		"mariadb":    newMySQL,
+		"mysql":      newMySQL,
+		"postgres":   newPostgreSQL,
+		"postgresql": newPostgreSQL,
+		"sqlite":     newSQLite3,
+		"sqlite3":    newSQLite3,
+		"sqlserver":  newSQLServer,
+	},
+	mutex: sync.RWMutex{},
+}
+
+// RegisterDriver make a driver available to Connect, names are case-insensitive and registering a name which
+// already exists will replace the existing driver.
+func RegisterDriver(name string, factory DriverFactory) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.factories[strings.ToLower(name)] = factory
+}
+
+// lookupDriver find the factory for a driver by name.
+func lookupDriver(name string) (DriverFactory, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	factory, ok := registry.factories[strings.ToLower(name)]
+
+	return factory, ok
+}
+
+// newMySQL create a MySQL driver from a configuration.
+func newMySQL(config Config) (Driver, error) {
+	return drivers.MySQL{
+		Host:     config.Host,
+		Name:     config.Name,
+		Password: config.Password,
+		Port:     config.Port,
+		Socket:   config.Socket,
+		TLS:      config.TLS,
+		Username: config.Username,
+	}, nil
+}
+
+// newPostgreSQL create a PostgreSQL driver from a configuration.
+func newPostgreSQL(config Config) (Driver, error) {
+	return drivers.PostgreSQL{
+		Host:     config.Host,
+		Name:     config.Name,
+		Password: config.Password,
+		Port:     config.Port,
+		TLS:      config.TLS,
+		Username: config.Username,
+	}, nil
+}
+
+// newSQLite3 create a SQLite3 driver from a configuration.
+func newSQLite3(config Config) (Driver, error) {
+	return drivers.SQLite3{
+		Filename: config.Name,
+	}, nil
+}
+
+// newSQLServer create a SQLServer driver from a configuration.
+func newSQLServer(config Config) (Driver, error) {
+	return drivers.SQLServer{
+		Host:     config.Host,
+		Name:     config.Name,
+		Password: config.Password,
+		Port:     config.Port,
+		TLS:      config.TLS,
+		Username: config.Username,
+	}, nil
+}
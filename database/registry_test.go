@@ -0,0 +1,66 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/database/drivers"
+	"github.com/sjdaws/pkg/errors"
+)
+
+func TestConnectWithDriver(t *testing.T) {
+	t.Parallel()
+
+	connection, err := database.ConnectWithDriver(drivers.SQLite3{Filename: ":memory:"}, database.Config{})
+	require.NoError(t, err)
+
+	assert.IsType(t, &database.Database{}, connection)
+	assert.Equal(t, "sqlite", connection.ORM().Name())
+}
+
+func TestConnectWithDriver_ErrMissingDriver(t *testing.T) {
+	t.Parallel()
+
+	connection, err := database.ConnectWithDriver(nil, database.Config{})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "driver must be provided")
+	assert.Nil(t, connection)
+}
+
+func TestRegisterDriver(t *testing.T) {
+	t.Parallel()
+
+	var received database.Config
+
+	database.RegisterDriver("Registered-SQLite", func(config database.Config) (database.Driver, error) {
+		received = config
+
+		return drivers.SQLite3{Filename: config.Name}, nil
+	})
+
+	config := database.Config{Driver: "registered-sqlite", Name: ":memory:"}
+
+	connection, err := database.Connect(config)
+	require.NoError(t, err)
+
+	assert.Equal(t, config, received)
+	assert.Equal(t, "sqlite", connection.ORM().Name())
+}
+
+func TestRegisterDriver_ErrFactory(t *testing.T) {
+	t.Parallel()
+
+	database.RegisterDriver("failing", func(_ database.Config) (database.Driver, error) {
+		return nil, errors.New("test")
+	})
+
+	connection, err := database.Connect(database.Config{Driver: "failing"})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to create driver: test")
+	assert.Nil(t, connection)
+}
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// resolver gorm plugin which balances reads across replicas, writes and transactions always use the primary.
+type resolver struct {
+	counter  *atomic.Uint64
+	replicas []gorm.ConnPool
+}
+
+// primaryKey setting which forces queries to use the primary.
+const primaryKey = "database:primary"
+
+// UsePrimary force queries made with orm to run on the primary database when replicas are configured.
+func UsePrimary(orm *gorm.DB) *gorm.DB {
+	return orm.Set(primaryKey, true).Session(&gorm.Session{}) //nolint:exhaustruct // Default session
+}
+
+// Initialize register callbacks which route reads before they are executed.
+func (r resolver) Initialize(orm *gorm.DB) error {
+	callbacks := orm.Callback()
+
+	err := callbacks.Query().Before("gorm:query").Register(r.Name(), r.route)
+	if err != nil {
+		return errors.Wrap(err, "unable to register replica routing")
+	}
+
+	err = callbacks.Row().Before("gorm:row").Register(r.Name(), r.route)
+	if err != nil {
+		return errors.Wrap(err, "unable to register replica routing")
+	}
+
+	return nil
+}
+
+// Name of the plugin.
+func (r resolver) Name() string {
+	return "database:replicas"
+}
+
+// route send a read to the next replica unless it must run on the primary.
+func (r resolver) route(orm *gorm.DB) {
+	if len(r.replicas) == 0 || orm.Error != nil {
+		return
+	}
+
+	// Transactions hold a single connection to the primary
+	if _, ok := orm.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+
+	if primary, ok := orm.Get(primaryKey); ok && primary == true {
+		return
+	}
+
+	// Locking reads must run on the primary
+	if _, ok := orm.Statement.Clauses["FOR"]; ok {
+		return
+	}
+
+	// Raw statements may be writes, e.g. INSERT ... RETURNING
+	if orm.Statement.SQL.Len() > 0 && !isRead(orm.Statement.SQL.String()) {
+		return
+	}
+
+	next := r.counter.Add(1) - 1
+	orm.Statement.ConnPool = r.replicas[next%uint64(len(r.replicas))]
+}
+
+// closeAll close connections, used to clean up after a failed connection.
+func closeAll(connections []*gorm.DB) {
+	for _, connection := range connections {
+		_ = closeConnection(connection)
+	}
+}
+
+// connectReplicas open a connection to each replica using the registered driver named by the primary config, each
+// replica uses its own pool options or the primary's if none are set.
+func connectReplicas(config Config) ([]*gorm.DB, error) {
+	if len(config.Replicas) == 0 {
+		return nil, nil
+	}
+
+	factory, ok := lookupDriver(config.Driver)
+	if !ok {
+		return nil, errors.New("unsupported database type requested for replicas: %s", config.Driver)
+	}
+
+	replicas := make([]*gorm.DB, 0, len(config.Replicas))
+
+	for index, replica := range config.Replicas {
+		if replica.Driver == "" {
+			replica.Driver = config.Driver
+		}
+
+		if !strings.EqualFold(replica.Driver, config.Driver) {
+			closeAll(replicas)
+
+			return nil, errors.New("replica %d driver must match primary driver: %s", index, replica.Driver)
+		}
+
+		driver, err := factory(replica)
+		if err != nil {
+			closeAll(replicas)
+
+			return nil, errors.Wrap(err, "unable to create driver for replica %d", index)
+		}
+
+		dialector, err := driver.GetDialector()
+		if err != nil {
+			closeAll(replicas)
+
+			return nil, errors.Wrap(err, "unable to create dialector for replica %d", index)
+		}
+
+		orm, err := gorm.Open(dialector, createConfiguration(config))
+		if err != nil {
+			closeAll(replicas)
+
+			return nil, errors.Wrap(translate(err), "unable to open connection to replica %d", index)
+		}
+
+		replicas = append(replicas, orm)
+
+		// Replicas without pool options share the primary's so a single setting applies everywhere
+		pool := replica.Pool
+		if pool == (Pool{}) {
+			pool = config.Pool
+		}
+
+		err = pool.configure(orm)
+		if err != nil {
+			closeAll(replicas)
+
+			return nil, err
+		}
+	}
+
+	return replicas, nil
+}
+
+// isRead determine if a statement only reads data.
+func isRead(statement string) bool {
+	statement = strings.ToUpper(strings.TrimSpace(statement))
+
+	return strings.HasPrefix(statement, "SELECT") && !strings.Contains(statement, " FOR UPDATE") &&
+		!strings.Contains(statement, " FOR SHARE")
+}
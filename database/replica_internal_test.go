@@ -0,0 +1,39 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectReplicas_Pool(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+
+	replicas, err := connectReplicas(Config{
+		Driver: "sqlite",
+		Name:   directory + "/primary.db",
+		Pool:   Pool{MaxOpen: 3},
+		Replicas: []Config{
+			{Name: directory + "/replica-1.db"},
+			{Name: directory + "/replica-2.db", Pool: Pool{MaxOpen: 5}},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		closeAll(replicas)
+	})
+
+	require.Len(t, replicas, 2)
+
+	// Replicas without pool options use the primary's
+	for index, expected := range []int{3, 5} {
+		pool, err := replicas[index].DB()
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, pool.Stats().MaxOpenConnections)
+	}
+}
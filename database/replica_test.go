@@ -0,0 +1,148 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/database/drivers"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+func TestConnect_Replicas(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+
+	// Each database has a different number of records so the source of a read can be identified
+	for index, name := range []string{"primary", "replica-1", "replica-2"} {
+		seeded, err := database.Connect(database.Config{Driver: "sqlite", Name: directory + "/" + name + ".db"})
+		require.NoError(t, err)
+
+		require.NoError(t, seeded.Migrate(modelmock.ModelMock{}))
+
+		for range index + 1 {
+			require.NoError(t, database.Repository[modelmock.ModelMock](seeded).Create(&modelmock.ModelMock{}))
+		}
+	}
+
+	connection, err := database.Connect(database.Config{
+		Driver: "sqlite",
+		Name:   directory + "/primary.db",
+		Replicas: []database.Config{
+			{Name: directory + "/replica-1.db"},
+			{Driver: "sqlite", Name: directory + "/replica-2.db"},
+		},
+	})
+	require.NoError(t, err)
+
+	instance := database.Repository[modelmock.ModelMock](connection)
+
+	// Reads alternate between replicas
+	sources := make([]int, 0, 4)
+
+	for range 4 {
+		models, err := instance.Get()
+		require.NoError(t, err)
+
+		sources = append(sources, len(models))
+	}
+
+	assert.ElementsMatch(t, []int{2, 3, 2, 3}, sources)
+
+	// Writes and forced reads use the primary
+	require.NoError(t, instance.Create(&modelmock.ModelMock{}))
+
+	models, err := instance.Primary().Get()
+	require.NoError(t, err)
+
+	assert.Len(t, models, 2)
+
+	// Transactions use the primary
+	err = connection.WithTransaction(t.Context(), func(tx database.Connection) error {
+		models, err = database.Repository[modelmock.ModelMock](tx).Get()
+
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, models, 2)
+
+	// Raw writes use the primary
+	require.NoError(t, connection.ORM().Exec("DELETE FROM model_mocks").Error)
+
+	count, err := instance.Primary().Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(0), count)
+
+	// Plans inspect the primary schema
+	plan, err := connection.Plan(t.Context(), modelmock.ModelMock{})
+	require.NoError(t, err)
+
+	assert.Empty(t, plan.Statements)
+}
+
+func TestConnect_Replicas_Errors(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		config   database.Config
+		expected string
+	}{
+		"driver mismatch": {
+			config: database.Config{
+				Driver:   "sqlite",
+				Name:     ":memory:",
+				Replicas: []database.Config{{Driver: "postgres"}},
+			},
+			expected: "replica 0 driver must match primary driver: postgres",
+		},
+		"invalid dialector": {
+			config: database.Config{
+				Driver:   "sqlite",
+				Name:     ":memory:",
+				Replicas: []database.Config{{Name: ""}},
+			},
+			expected: "unable to create dialector for replica 0: database name must be provided",
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			connection, err := database.Connect(testcase.config)
+			require.Error(t, err)
+
+			require.EqualError(t, err, testcase.expected)
+			assert.Nil(t, connection)
+		})
+	}
+}
+
+func TestConnectWithDriver_Replicas(t *testing.T) {
+	t.Parallel()
+
+	// Replicas use the registered driver named in config even though the primary driver is provided directly
+	config := database.Config{Driver: "sqlite", Replicas: []database.Config{{Name: ":memory:"}}}
+
+	connection, err := database.ConnectWithDriver(drivers.SQLite3{Filename: ":memory:"}, config)
+	require.NoError(t, err)
+
+	require.NoError(t, connection.Close())
+}
+
+func TestConnectWithDriver_Replicas_ErrUnknownDriver(t *testing.T) {
+	t.Parallel()
+
+	config := database.Config{Replicas: []database.Config{{Name: ":memory:"}}}
+
+	connection, err := database.ConnectWithDriver(drivers.SQLite3{Filename: ":memory:"}, config)
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unsupported database type requested for replicas: ")
+	assert.Nil(t, connection)
+}
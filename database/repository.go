@@ -1,28 +1,64 @@
 package database
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"github.com/carlmjohnson/truthy"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"gorm.io/gorm/utils"
 
 	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/logging"
 )
 
 // Persister interface.
 type Persister[m Model] interface {
+	Avg(column string, where ...any) (float64, error)
 	BypassDelete() Persister[m]
+	BypassTenant(reason string) Persister[m]
+	Chunk(size int, fn func(models []m) error, where ...any) error
+	Count(where ...any) (int64, error)
 	Create(model *m) error
+	CreateMany(models []m, batchSize int) error
+	Cursor(cursor string, size int, where ...any) (CursorPage[m], error)
 	Delete(model *m, where ...any) error
+	DeleteWhere(where ...any) (int64, error)
+	Each(fn func(model m) error, where ...any) error
+	Exists(where ...any) (bool, error)
+	ForceDelete(model *m, where ...any) error
 	Get(where ...any) ([]m, error)
+	History(model *m) ([]AuditEntry, error)
+	Hook(event Event, hook Hook[m])
+	Limit(size int) Persister[m]
+	Lock(mode LockMode) Persister[m]
+	Max(column string, where ...any) (float64, error)
+	Min(column string, where ...any) (float64, error)
 	One(where ...any) (*m, error)
+	OnlyDeleted() Persister[m]
 	OrderBy(order ...Order) Persister[m]
+	Paginate(page int, size int, where ...any) (Page[m], error)
 	PartOf(connection *gorm.DB) Persister[m]
+	Pluck(column string, where ...any) ([]any, error)
+	Primary() Persister[m]
+	Purge(olderThan time.Duration, batchSize int) (int64, error)
 	Restore(model *m) error
+	Rows(where ...any) (*Iterator[m], error)
+	Subscribe(listener Listener[m]) func()
+	Sum(column string, where ...any) (float64, error)
 	Then(relationship string, where ...any) Persister[m]
 	Update(model *m) error
+	UpdateFields(model *m, fields ...string) error
+	UpdateWhere(values map[string]any, where ...any) (int64, error)
+	Upsert(model *m, conflictColumns []string, updateColumns []string) error
 	With(relationship string, where ...any) Persister[m]
+	WithContext(ctx context.Context) Persister[m]
 }
 
-// Or type for holding where queries which should be OR.
+// Or type for holding where queries which should be OR, queries may be filters, models, maps or raw queries.
 type Or []any
 
 // Order parameter for Persister.OrderBy.
@@ -39,12 +75,21 @@ type Raw struct {
 
 // repository base repository which all repositories extend.
 type repository[m Model] struct {
-	connection *gorm.DB
-	model      m
-	models     []m
-	order      []Order
-	relations  []relation
-	unscoped   bool
+	audit          *Audit
+	connection     *gorm.DB
+	hooks          *Hooks
+	limit          int
+	lock           LockMode
+	logger         logging.Logger
+	model          m
+	models         []m
+	onlyDeleted    bool
+	order          []Order
+	pending        *pending
+	relations      []relation
+	retry          *retrier
+	unscoped       bool
+	unscopedTenant bool
 }
 
 // relation to fetch with the initial request.
@@ -62,12 +107,35 @@ func Repository[m Model](connection Connection) Persister[m] {
 	var model m
 
 	instance := repository[m]{
-		connection: connection.ORM(),
-		model:      model,
-		models:     make([]m, 0),
-		order:      make([]Order, 0),
-		relations:  make([]relation, 0),
-		unscoped:   false,
+		audit:          nil,
+		connection:     connection.ORM(),
+		hooks:          NewHooks(),
+		limit:          0,
+		lock:           0,
+		logger:         logging.Default(),
+		model:          model,
+		models:         make([]m, 0),
+		onlyDeleted:    false,
+		order:          make([]Order, 0),
+		pending:        nil,
+		relations:      make([]relation, 0),
+		retry:          nil,
+		unscoped:       false,
+		unscopedTenant: false,
+	}
+
+	if keeper, ok := connection.(hookKeeper); ok && keeper.Hooks() != nil {
+		instance.hooks = keeper.Hooks()
+	}
+
+	if database, ok := connection.(*Database); ok {
+		instance.audit = database.audit
+		instance.pending = database.pending
+		instance.retry = database.retry
+
+		if database.logger != nil {
+			instance.logger = database.logger
+		}
 	}
 
 	return instance
@@ -83,22 +151,35 @@ func (r repository[m]) BypassDelete() Persister[m] {
 
 // Create a new record from a model.
 func (r repository[m]) Create(model *m) error {
-	result := r.connection.Create(model)
-	if result.Error != nil {
-		return errors.Wrap(result.Error, "unable to create record")
-	}
+	return r.lifecycle(BeforeCreate, AfterCreate, []*m{model}, func(tx repository[m]) error {
+		err := tx.assignTenant(model)
+		if err != nil {
+			return err
+		}
+
+		result := tx.writeOnce("create record", func() *gorm.DB { return tx.connection.Create(model) })
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "unable to create record")
+		}
 
-	return nil
+		return nil
+	})
 }
 
-// Delete a record.
+// Delete a record, ErrNoResults is returned if the record belongs to another tenant.
 func (r repository[m]) Delete(model *m, where ...any) error {
-	result := r.connection.Delete(model, where...)
-	if result.Error != nil {
-		return errors.Wrap(result.Error, "unable to delete record")
-	}
+	return r.lifecycle(BeforeDelete, AfterDelete, []*m{model}, func(tx repository[m]) error {
+		result := tx.write("delete record", func() *gorm.DB { return tx.tenanted(tx.connection).Delete(model, where...) })
+		if result.Error == nil && result.RowsAffected == 0 {
+			tx.unaffected(result, model)
+		}
+
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "unable to delete record")
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // Get record(s) from a query.
@@ -115,6 +196,14 @@ func (r repository[m]) Get(where ...any) ([]m, error) {
 	return r.models, nil
 }
 
+// Limit the number of records fetched by a query, a size less than 1 removes the limit.
+func (r repository[m]) Limit(size int) Persister[m] {
+	transaction := r
+	transaction.limit = size
+
+	return transaction
+}
+
 // One fetches a single record from a query.
 func (r repository[m]) One(where ...any) (*m, error) {
 	result := r.query(where...).First(&r.model)
@@ -146,14 +235,26 @@ func (r repository[m]) PartOf(connection *gorm.DB) Persister[m] {
 	return transaction
 }
 
-// Restore a deleted record.
+// Primary run queries on the primary database, so records written moments ago can be read back.
+func (r repository[m]) Primary() Persister[m] {
+	transaction := r
+	transaction.connection = UsePrimary(r.connection)
+
+	return transaction
+}
+
+// Restore a deleted record along with children which were deleted at the same time or later, so children should be
+// deleted after their parent. Only the record runs hooks and is audited, children are restored in the same
+// transaction. ErrNoResults is returned if the record belongs to another tenant.
 func (r repository[m]) Restore(model *m) error {
-	result := r.connection.Unscoped().Model(model).Update("deleted_at", nil)
-	if result.Error != nil {
-		return errors.Wrap(result.Error, "unable to restore record")
-	}
+	return r.lifecycle(BeforeRestore, AfterRestore, []*m{model}, func(tx repository[m]) error {
+		err := tx.restore(model)
+		if err != nil {
+			return errors.Wrap(err, "unable to restore record")
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // Then eager load relationship after initial query is complete.
@@ -165,14 +266,17 @@ func (r repository[m]) Then(relationship string, where ...any) Persister[m] {
 	return transaction
 }
 
-// Update a record from a model.
+// Update a record from a model, ErrConflict is returned if the model is versioned and the record was changed since
+// the model was read.
 func (r repository[m]) Update(model *m) error {
-	result := r.connection.Save(model)
-	if result.Error != nil {
-		return errors.Wrap(result.Error, "unable to update record")
-	}
+	return r.lifecycle(BeforeUpdate, AfterUpdate, []*m{model}, func(tx repository[m]) error {
+		result := tx.writeOnce("update record", func() *gorm.DB { return tx.save(model) })
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "unable to update record")
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // With get a relationship with query, otherwise return nothing.
@@ -184,6 +288,41 @@ func (r repository[m]) With(relationship string, where ...any) Persister[m] {
 	return transaction
 }
 
+// WithContext use a context for all queries, allowing cancellation, deadlines and values to reach the database.
+func (r repository[m]) WithContext(ctx context.Context) Persister[m] {
+	transaction := r
+	transaction.connection = r.connection.WithContext(ctx)
+
+	return transaction
+}
+
+// write run a write, retrying transient failures when the repository isn't part of a transaction.
+func (r repository[m]) write(operation string, fn func() *gorm.DB) *gorm.DB {
+	var result *gorm.DB
+
+	_ = r.retry.do(r.connection.Statement.Context, r.connection, operation, func() error {
+		result = fn()
+
+		return result.Error
+	})
+
+	return result
+}
+
+// writeOnce run a write which must not be applied twice, such as an insert, retrying only failures which guarantee
+// nothing was written when the repository isn't part of a transaction.
+func (r repository[m]) writeOnce(operation string, fn func() *gorm.DB) *gorm.DB {
+	var result *gorm.DB
+
+	_ = r.retry.doOnce(r.connection.Statement.Context, r.connection, operation, func() error {
+		result = fn()
+
+		return result.Error
+	})
+
+	return result
+}
+
 // addMeta eager load requested relationships, process order.
 func (r repository[m]) addMeta(transaction *gorm.DB) *gorm.DB {
 	if r.unscoped {
@@ -194,34 +333,166 @@ func (r repository[m]) addMeta(transaction *gorm.DB) *gorm.DB {
 		transaction = transaction.Order(truthy.Cond(by.Descending, by.Column+" desc", by.Column+" asc"))
 	}
 
+	if r.limit > 0 {
+		transaction = transaction.Limit(r.limit)
+	}
+
+	transaction, err := r.relate(transaction)
+	if err != nil {
+		return failed(transaction, err)
+	}
+
+	return r.applyLock(transaction)
+}
+
+// relate eager load requested relationships, tenant scoped relationships are limited to the tenant at every level.
+func (r repository[m]) relate(transaction *gorm.DB) (*gorm.DB, error) {
+	paths := make([]string, 0, len(r.relations))
+	preloads := make(map[string][]any)
+	scoped := make(map[string]bool)
+
 	for _, relationship := range r.relations {
+		scopes, err := r.relationTenancy(relationship.key)
+		if err != nil {
+			return transaction, err
+		}
+
+		segments := strings.Split(relationship.key, ".")
+
 		// Use inner join for hasone relationships, this will cause no records to be returned if join is empty
 		if relationship.join {
 			transaction = transaction.InnerJoins(relationship.key, relationship.where...)
 
+			// Joined relationships are aliased by their path
+			for index, scope := range scopes {
+				if scope != nil {
+					transaction = transaction.Where(scope.condition(utils.JoinNestedRelationNames(segments[:index+1])))
+				}
+			}
+
 			continue
 		}
 
 		// Preload hasmany relationships, this will do a second select for the relationship
-		transaction = transaction.Preload(relationship.key, relationship.where...)
+		for index := range segments {
+			path := strings.Join(segments[:index+1], ".")
+
+			var scope *tenancy
+			if index < len(scopes) {
+				scope = scopes[index]
+			}
+
+			if _, ok := preloads[path]; !ok && (scope != nil || path == relationship.key) {
+				paths = append(paths, path)
+				preloads[path] = nil
+			}
+
+			if scope != nil && !scoped[path] {
+				preloads[path] = append(preloads[path], scope.condition(""))
+				scoped[path] = true
+			}
+		}
+
+		preloads[relationship.key] = append(preloads[relationship.key], relationship.where...)
 	}
 
-	return transaction
+	for _, path := range paths {
+		transaction = transaction.Preload(path, preloads[path]...)
+	}
+
+	return transaction, nil
 }
 
-// query starts a query using map expectedParameters.
-func (r repository[m]) query(where ...any) *gorm.DB {
-	query := r.connection
+// column resolve a column name against the model schema. Columns can be prefixed with the model table, or with the
+// path of a joined relationship such as Lead.name, in which case they are resolved against the relationship schema.
+func (r repository[m]) column(name string) (clause.Column, error) {
+	modelSchema, err := r.schema()
+	if err != nil {
+		return clause.Column{}, err
+	}
+
+	table := clause.CurrentTable
+	columnSchema := modelSchema
+
+	separator := strings.LastIndex(name, ".")
+	if separator >= 0 {
+		table, columnSchema = r.prefixed(modelSchema, name[:separator])
+		if columnSchema == nil {
+			return clause.Column{}, errors.New("unknown column: %s", name)
+		}
+	}
+
+	field := columnSchema.LookUpField(name[separator+1:])
+	if field == nil || field.DBName == "" {
+		return clause.Column{}, errors.New("unknown column: %s", name)
+	}
+
+	return clause.Column{Alias: "", Name: field.DBName, Raw: false, Table: table}, nil
+}
+
+// prefixed resolve a column prefix to the table it refers to in a query and its schema, prefixes are either the model
+// table or the path of a joined relationship. A nil schema is returned if the prefix isn't part of the query.
+func (r repository[m]) prefixed(modelSchema *schema.Schema, prefix string) (string, *schema.Schema) {
+	if prefix == modelSchema.Table {
+		return prefix, modelSchema
+	}
+
+	segments := strings.Split(prefix, ".")
+
+	for _, relationship := range r.relations {
+		if !relationship.join || (relationship.key != prefix && !strings.HasPrefix(relationship.key, prefix+".")) {
+			continue
+		}
+
+		current := modelSchema
+
+		for _, segment := range segments {
+			related, ok := current.Relationships.Relations[segment]
+			if !ok {
+				return "", nil
+			}
+
+			current = related.FieldSchema
+		}
+
+		// Joined relationships are aliased by their path
+		return utils.JoinNestedRelationNames(segments), current
+	}
+
+	return "", nil
+}
+
+// condition build a where condition into an expression.
+func (r repository[m]) condition(where any) (clause.Expression, error) {
+	switch state := where.(type) {
+	case nil:
+		return nil, nil //nolint:nilnil // An empty condition has no expression
+	case Filter:
+		return state.build(r)
+	case Raw:
+		return clause.Expr{SQL: state.Query, Vars: state.Parameters, WithoutParentheses: false}, nil
+	default:
+		return clause.And(r.connection.Statement.BuildCondition(where)...), nil
+	}
+}
+
+// conditions apply where conditions to a query.
+func (r repository[m]) conditions(where ...any) *gorm.DB {
+	query := r.trashed(r.tenanted(r.connection))
 
 	for _, condition := range where {
 		switch state := condition.(type) {
-		case Or:
-			subQuery := r.connection
-			for _, orCondition := range state {
-				subQuery = subQuery.Or(orCondition)
+		case Filter:
+			expression, err := state.build(r)
+			if err != nil {
+				query = failed(query, err)
+
+				continue
 			}
 
-			query = query.Where(subQuery)
+			if expression != nil {
+				query = query.Where(expression)
+			}
 
 		case Raw:
 			query = query.Where(state.Query, state.Parameters...)
@@ -231,5 +502,39 @@ func (r repository[m]) query(where ...any) *gorm.DB {
 		}
 	}
 
-	return r.addMeta(query)
+	return query
+}
+
+// query starts a query using map expectedParameters.
+func (r repository[m]) query(where ...any) *gorm.DB {
+	return r.addMeta(r.conditions(where...))
+}
+
+// schema parse the schema for the model.
+func (r repository[m]) schema() (*schema.Schema, error) {
+	transaction := r.connection.Model(&r.model)
+
+	err := transaction.Statement.Parse(&r.model)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse model schema")
+	}
+
+	return transaction.Statement.Schema, nil
+}
+
+// scope starts a query without ordering or eager loading, for use with aggregates.
+func (r repository[m]) scope(where ...any) *gorm.DB {
+	transaction := r.conditions(where...).Model(&r.model)
+
+	if r.unscoped {
+		transaction = transaction.Unscoped()
+	}
+
+	for _, relationship := range r.relations {
+		if relationship.join {
+			transaction = transaction.InnerJoins(relationship.key, relationship.where...)
+		}
+	}
+
+	return transaction
 }
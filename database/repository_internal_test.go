@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql/driver"
 	"testing"
 
@@ -21,6 +22,9 @@ const (
 	updateQuery  = "UPDATE `model_mocks` SET `deleted_at`=?,`test`=? WHERE `model_mocks`.`deleted_at` IS NULL AND `id` = ?"
 )
 
+// contextKey key used to store values in a context.
+type contextKey struct{}
+
 func TestRepository_BypassDelete(t *testing.T) {
 	t.Parallel()
 
@@ -212,6 +216,22 @@ func TestRepository_Get_QueryError(t *testing.T) {
 	assert.Nil(t, result)
 }
 
+func TestRepository_Limit(t *testing.T) {
+	t.Parallel()
+
+	connection, _ := ormmock.New(t)
+	instance := Repository[modelmock.ModelMock](&Database{orm: connection})
+
+	result := instance.Limit(5)
+
+	assert.NotEqual(t, instance, result)
+
+	actual, ok := result.(repository[modelmock.ModelMock])
+
+	require.True(t, ok)
+	assert.Equal(t, 5, actual.limit)
+}
+
 func TestRepository_One(t *testing.T) {
 	t.Parallel()
 
@@ -489,20 +509,44 @@ func TestRepository_With(t *testing.T) {
 	assert.Equal(t, []relation{{join: true, key: "Relation"}}, actual.relations)
 }
 
+func TestRepository_WithContext(t *testing.T) {
+	t.Parallel()
+
+	connection, _ := ormmock.New(t)
+	instance := Repository[modelmock.ModelMock](&Database{orm: connection})
+
+	ctx := context.WithValue(context.Background(), contextKey{}, "value")
+
+	result := instance.WithContext(ctx)
+
+	assert.NotEqual(t, instance, result)
+
+	actual, ok := result.(repository[modelmock.ModelMock])
+
+	require.True(t, ok)
+	assert.Equal(t, ctx, actual.connection.Statement.Context)
+}
+
+func TestRepository_WithContext_Cancelled(t *testing.T) {
+	t.Parallel()
+
+	connection, _ := ormmock.New(t)
+	instance := Repository[modelmock.ModelMock](&Database{orm: connection})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := instance.WithContext(ctx).Get()
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to fetch records: context canceled")
+	assert.Nil(t, result)
+}
+
 func TestRepository_addMeta(t *testing.T) {
 	t.Parallel()
 
-	connection, err := Connect(
-		false,
-		"sqlite",
-		"",
-		":memory:",
-		"",
-		0,
-		"",
-		"",
-		"",
-	)
+	connection, err := Connect(Config{Driver: "sqlite", Name: ":memory:"})
 	require.NoError(t, err)
 
 	actual := repository[modelmock.ModelMock]{
@@ -532,7 +576,15 @@ func TestRepository_addMeta(t *testing.T) {
 		transaction.Statement.Clauses,
 	)
 
+	// add limit
+	actual.order = nil
+	actual.limit = 5
+	transaction = actual.addMeta(connection.orm)
+
+	assert.Equal(t, clause.Limit{Limit: &actual.limit, Offset: 0}, transaction.Statement.Clauses["LIMIT"].Expression)
+
 	// add eager load
+	actual.limit = 0
 	actual.relations = []relation{{join: false, key: "Relation"}}
 	transaction = actual.addMeta(connection.orm)
 
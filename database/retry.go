@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/logging"
+)
+
+// Retry policy for transient failures such as deadlocks, serialization failures and dropped connections. Managed
+// transactions are retried as a whole, repository writes are retried individually when not part of a transaction.
+// Writes which can't safely be repeated, such as inserts and versioned updates, aren't retried after a dropped
+// connection since the write may have been applied before the connection was lost.
+type Retry struct {
+	// Attempts maximum number of attempts including the first, retries are disabled when less than two
+	Attempts int
+	// Delay before the first retry, the delay doubles for each subsequent retry
+	Delay time.Duration
+	// MaxDelay upper bound for the delay between attempts, zero means unbounded
+	MaxDelay time.Duration
+	// Retryable determine if an error is transient, IsRetryable is used if nil
+	Retryable func(err error) bool
+}
+
+// retrier retries operations according to a policy.
+type retrier struct {
+	logger logging.Logger
+	policy Retry
+}
+
+// mysqlLockWaitTimeout MySQL error number returned when a lock can't be acquired in time.
+const mysqlLockWaitTimeout = 1205
+
+// IsRetryable determine if an error is transient and the operation may succeed if attempted again.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrConnection) || errors.Is(err, ErrDeadlock) || errors.Is(err, ErrSerializationFailure) {
+		return true
+	}
+
+	var mysqlError *mysql.MySQLError
+
+	return errors.As(err, &mysqlError) && mysqlError.Number == mysqlLockWaitTimeout
+}
+
+// newRetrier create a retrier from a configuration, nil is returned if retries are disabled.
+func newRetrier(config Config) *retrier {
+	if config.Retry.Attempts < 2 { //nolint:mnd // A single attempt never retries
+		return nil
+	}
+
+	log := config.Logger
+	if log == nil {
+		log = logging.Default()
+	}
+
+	return &retrier{
+		logger: log,
+		policy: config.Retry,
+	}
+}
+
+// backoff calculate the delay before a retry using exponential backoff with jitter, between half and all of the
+// exponential delay is used so concurrent callers don't retry in lockstep.
+func (r Retry) backoff(retry int) time.Duration {
+	delay := r.Delay << (retry - 1)
+	if delay <= 0 || (r.MaxDelay > 0 && delay > r.MaxDelay) {
+		delay = r.MaxDelay
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2 //nolint:mnd // Jitter is applied to half of the delay
+
+	return half + rand.N(delay-half+1) //nolint:gosec // Jitter doesn't need a secure source
+}
+
+// retryable determine if an error should be retried using the policy classifier.
+func (r Retry) retryable(err error) bool {
+	if r.Retryable != nil {
+		return r.Retryable(err)
+	}
+
+	return IsRetryable(err)
+}
+
+// do run fn, retrying transient failures. Operations on a transaction are never retried as the transaction must be
+// retried as a whole.
+func (r *retrier) do(ctx context.Context, orm *gorm.DB, operation string, fn func() error) error {
+	return r.run(ctx, orm, operation, fn, func(err error) bool {
+		return r.policy.retryable(err)
+	})
+}
+
+// doOnce run fn which must not be applied twice, only retrying failures which guarantee nothing was written. Dropped
+// connections aren't retried since fn may have been applied before the connection was lost.
+func (r *retrier) doOnce(ctx context.Context, orm *gorm.DB, operation string, fn func() error) error {
+	return r.run(ctx, orm, operation, fn, func(err error) bool {
+		return !errors.Is(err, ErrConnection) && r.policy.retryable(err)
+	})
+}
+
+// run fn, retrying failures accepted by retryable.
+func (r *retrier) run(ctx context.Context, orm *gorm.DB, operation string, fn func() error, retryable func(err error) bool) error {
+	err := fn()
+
+	if r == nil || orm == nil {
+		return err
+	}
+
+	if _, ok := orm.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return err
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for attempt := 2; err != nil && attempt <= r.policy.Attempts && retryable(err); attempt++ {
+		delay := r.policy.backoff(attempt - 1)
+
+		r.logger.Warn("%s failed, retrying in %s (attempt %d of %d): %v", operation, delay, attempt, r.policy.Attempts, err)
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return err
+		case <-timer.C:
+		}
+
+		err = fn()
+	}
+
+	return err
+}
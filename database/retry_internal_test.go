@@ -0,0 +1,57 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry_backoff(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		policy  Retry
+		retry   int
+		minimum time.Duration
+		maximum time.Duration
+	}{
+		"capped": {
+			policy:  Retry{Attempts: 5, Delay: 10 * time.Millisecond, MaxDelay: 25 * time.Millisecond, Retryable: nil},
+			retry:   3,
+			minimum: 12500 * time.Microsecond,
+			maximum: 25 * time.Millisecond,
+		},
+		"first retry": {
+			policy:  Retry{Attempts: 5, Delay: 10 * time.Millisecond, MaxDelay: 0, Retryable: nil},
+			retry:   1,
+			minimum: 5 * time.Millisecond,
+			maximum: 10 * time.Millisecond,
+		},
+		"no delay": {
+			policy:  Retry{Attempts: 5, Delay: 0, MaxDelay: 0, Retryable: nil},
+			retry:   2,
+			minimum: 0,
+			maximum: 0,
+		},
+		"third retry": {
+			policy:  Retry{Attempts: 5, Delay: 10 * time.Millisecond, MaxDelay: 0, Retryable: nil},
+			retry:   3,
+			minimum: 20 * time.Millisecond,
+			maximum: 40 * time.Millisecond,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for range 100 {
+				delay := testcase.policy.backoff(testcase.retry)
+
+				assert.GreaterOrEqual(t, delay, testcase.minimum)
+				assert.LessOrEqual(t, delay, testcase.maximum)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/truthy"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/errors"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+	"github.com/sjdaws/pkg/testing/logging/logmock"
+)
+
+var errCustom = errors.New("custom")
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"connection": {
+			err:      database.ErrConnection,
+			expected: true,
+		},
+		"deadlock": {
+			err:      errors.Wrap(database.ErrDeadlock, "unable to update record"),
+			expected: true,
+		},
+		"duplicate key": {
+			err:      database.ErrDuplicateKey,
+			expected: false,
+		},
+		"lock wait timeout": {
+			err:      &mysql.MySQLError{Number: 1205, SQLState: [5]byte{}, Message: "Lock wait timeout exceeded"},
+			expected: true,
+		},
+		"nil": {
+			err:      nil,
+			expected: false,
+		},
+		"other mysql error": {
+			err:      &mysql.MySQLError{Number: 1064, SQLState: [5]byte{}, Message: "syntax error"},
+			expected: false,
+		},
+		"serialization failure": {
+			err:      database.ErrSerializationFailure,
+			expected: true,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testcase.expected, database.IsRetryable(testcase.err))
+		})
+	}
+}
+
+func TestConnection_WithTransaction_Retry(t *testing.T) {
+	t.Parallel()
+
+	testcases := map[string]struct {
+		failures int
+		err      error
+		retry    database.Retry
+		attempts int
+		expected error
+		logs     int
+	}{
+		"disabled": {
+			failures: 1,
+			err:      database.ErrDeadlock,
+			retry:    database.Retry{Attempts: 1, Delay: time.Millisecond, MaxDelay: 0, Retryable: nil},
+			attempts: 1,
+			expected: database.ErrDeadlock,
+			logs:     0,
+		},
+		"exhausted": {
+			failures: 5,
+			err:      database.ErrSerializationFailure,
+			retry:    database.Retry{Attempts: 3, Delay: time.Millisecond, MaxDelay: 0, Retryable: nil},
+			attempts: 3,
+			expected: database.ErrSerializationFailure,
+			logs:     2,
+		},
+		"custom classifier": {
+			failures: 1,
+			err:      errCustom,
+			retry: database.Retry{
+				Attempts:  3,
+				Delay:     0,
+				MaxDelay:  0,
+				Retryable: func(err error) bool { return errors.Is(err, errCustom) },
+			},
+			attempts: 2,
+			expected: nil,
+			logs:     1,
+		},
+		"not retryable": {
+			failures: 1,
+			err:      errCustom,
+			retry:    database.Retry{Attempts: 3, Delay: time.Millisecond, MaxDelay: 0, Retryable: nil},
+			attempts: 1,
+			expected: errCustom,
+			logs:     0,
+		},
+		"recovered": {
+			failures: 2,
+			err:      database.ErrDeadlock,
+			retry:    database.Retry{Attempts: 3, Delay: time.Millisecond, MaxDelay: 2 * time.Millisecond, Retryable: nil},
+			attempts: 3,
+			expected: nil,
+			logs:     2,
+		},
+	}
+
+	for name, testcase := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			log := logmock.New()
+
+			connection, err := database.Connect(database.Config{
+				Driver: "sqlite",
+				Logger: log,
+				Name:   t.TempDir() + "/test.db",
+				Retry:  testcase.retry,
+			})
+			require.NoError(t, err)
+
+			err = connection.Migrate(modelmock.ModelMock{})
+			require.NoError(t, err)
+
+			attempts := 0
+
+			err = connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+				attempts++
+
+				err := database.Repository[modelmock.ModelMock](tx).Create(&modelmock.ModelMock{Test: true})
+				require.NoError(t, err)
+
+				if attempts <= testcase.failures {
+					return testcase.err
+				}
+
+				return nil
+			})
+
+			if testcase.expected != nil {
+				require.ErrorIs(t, err, testcase.expected)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, testcase.attempts, attempts)
+			assert.Len(t, log.GetAllLogs(), testcase.logs)
+
+			// Failed attempts are rolled back
+			count, err := database.Repository[modelmock.ModelMock](connection).Count()
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(truthy.Cond(testcase.expected == nil, 1, 0)), count)
+		})
+	}
+}
+
+func TestConnection_WithTransaction_RetryCancelled(t *testing.T) {
+	t.Parallel()
+
+	connection, err := database.Connect(database.Config{
+		Driver: "sqlite",
+		Logger: logmock.New(),
+		Name:   ":memory:",
+		Retry:  database.Retry{Attempts: 5, Delay: time.Hour, MaxDelay: 0, Retryable: nil},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+
+	err = connection.WithTransaction(ctx, func(_ database.Connection) error {
+		attempts++
+
+		cancel()
+
+		return database.ErrDeadlock
+	})
+	require.ErrorIs(t, err, database.ErrDeadlock)
+
+	assert.Equal(t, 1, attempts)
+}
+
+func TestConnection_WithTransaction_RetryNested(t *testing.T) {
+	t.Parallel()
+
+	connection, err := database.Connect(database.Config{
+		Driver: "sqlite",
+		Logger: logmock.New(),
+		Name:   ":memory:",
+		Retry:  database.Retry{Attempts: 2, Delay: 0, MaxDelay: 0, Retryable: nil},
+	})
+	require.NoError(t, err)
+
+	inner := 0
+	outer := 0
+
+	err = connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+		outer++
+
+		return tx.WithTransaction(context.Background(), func(_ database.Connection) error {
+			inner++
+
+			return database.ErrDeadlock
+		})
+	})
+	require.ErrorIs(t, err, database.ErrDeadlock)
+
+	// Savepoints are never retried, the outer transaction is retried as a whole
+	assert.Equal(t, 2, outer)
+	assert.Equal(t, 2, inner)
+}
+
+func TestRepository_Retry(t *testing.T) {
+	t.Parallel()
+
+	log := logmock.New()
+
+	connection, err := database.Connect(database.Config{
+		Driver: "sqlite",
+		Logger: log,
+		Name:   t.TempDir() + "/test.db",
+		Retry:  database.Retry{Attempts: 3, Delay: time.Millisecond, MaxDelay: 0, Retryable: nil},
+	})
+	require.NoError(t, err)
+
+	err = connection.Migrate(modelmock.ModelMock{})
+	require.NoError(t, err)
+
+	failures := 2
+
+	// Fail the first statements as if the database detected a deadlock
+	err = connection.ORM().Callback().Create().Before("gorm:create").Register("test:deadlock", func(orm *gorm.DB) {
+		if failures > 0 {
+			failures--
+
+			_ = orm.AddError(database.ErrDeadlock)
+		}
+	})
+	require.NoError(t, err)
+
+	err = database.Repository[modelmock.ModelMock](connection).Create(&modelmock.ModelMock{Test: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, failures)
+	require.Len(t, log.GetAllLogs(), 2)
+	assert.Equal(t, "warn", log.GetLastLevel())
+	assert.Contains(t, log.GetLastMessage(), "create record failed, retrying in")
+	assert.Contains(t, log.GetLastMessage(), "(attempt 3 of 3): deadlock detected")
+
+	count, err := database.Repository[modelmock.ModelMock](connection).Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), count)
+}
+
+func TestRepository_Retry_Connection(t *testing.T) {
+	t.Parallel()
+
+	connection, err := database.Connect(database.Config{
+		Driver: "sqlite",
+		Logger: logmock.New(),
+		Name:   t.TempDir() + "/test.db",
+		Retry:  database.Retry{Attempts: 3, Delay: time.Millisecond, MaxDelay: 0, Retryable: nil},
+	})
+	require.NoError(t, err)
+
+	err = connection.Migrate(modelmock.ModelMock{})
+	require.NoError(t, err)
+
+	creates := 0
+
+	// Drop the connection after the insert was applied
+	err = connection.ORM().Callback().Create().After("gorm:create").Register("test:dropped", func(orm *gorm.DB) {
+		creates++
+
+		_ = orm.AddError(database.ErrConnection)
+	})
+	require.NoError(t, err)
+
+	deletes := 1
+
+	// Drop the connection before the delete is applied
+	err = connection.ORM().Callback().Delete().Before("gorm:delete").Register("test:interrupted", func(orm *gorm.DB) {
+		if deletes > 0 {
+			deletes--
+
+			_ = orm.AddError(database.ErrConnection)
+		}
+	})
+	require.NoError(t, err)
+
+	repository := database.Repository[modelmock.ModelMock](connection)
+
+	// Inserts aren't repeated since they may have been applied
+	err = repository.Create(&modelmock.ModelMock{Test: true})
+	require.ErrorIs(t, err, database.ErrConnection)
+
+	err = repository.CreateMany([]modelmock.ModelMock{{}, {}}, 1)
+	require.ErrorIs(t, err, database.ErrConnection)
+
+	err = repository.Upsert(&modelmock.ModelMock{ID: 10}, []string{"ID"}, nil)
+	require.ErrorIs(t, err, database.ErrConnection)
+
+	assert.Equal(t, 3, creates)
+
+	count, err := repository.Count()
+	require.NoError(t, err)
+
+	// Batches are created in a transaction so the failed batch was rolled back
+	assert.Equal(t, int64(2), count)
+
+	// Deletes can be repeated safely
+	deleted, err := repository.DeleteWhere(database.Gt("id", 0))
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, deletes)
+	assert.Equal(t, int64(2), deleted)
+}
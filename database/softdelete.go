@@ -0,0 +1,313 @@
+package database
+
+import (
+	"database/sql"
+	"reflect"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// deletedColumn column used to soft delete records.
+const deletedColumn = "deleted_at"
+
+// ErrNotSoftDeletable error returned when soft delete features are used with a model without a deleted_at column.
+var ErrNotSoftDeletable = errors.New("model doesn't have a deleted_at column")
+
+// ForceDelete permanently delete a record, even if the model supports soft deletes. ErrNoResults is returned if the
+// record belongs to another tenant.
+func (r repository[m]) ForceDelete(model *m, where ...any) error {
+	return r.lifecycle(BeforeDelete, AfterDelete, []*m{model}, func(tx repository[m]) error {
+		result := tx.write("force delete record", func() *gorm.DB {
+			return tx.tenanted(tx.connection.Unscoped()).Delete(model, where...)
+		})
+		if result.Error == nil && result.RowsAffected == 0 {
+			tx.unaffected(result, model)
+		}
+
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "unable to force delete record")
+		}
+
+		return nil
+	})
+}
+
+// OnlyDeleted return only deleted records.
+func (r repository[m]) OnlyDeleted() Persister[m] {
+	transaction := r
+	transaction.onlyDeleted = true
+	transaction.unscoped = true
+
+	return transaction
+}
+
+// Purge permanently delete records which were soft deleted more than olderThan ago, returning the number of records
+// purged. Records are deleted in batches of batchSize so each statement only holds locks briefly, which allows
+// retention policies to run as scheduled jobs. Purges are bulk deletes so they don't run hooks or write audit entries.
+func (r repository[m]) Purge(olderThan time.Duration, batchSize int) (int64, error) {
+	if batchSize < 1 {
+		return 0, errors.New("batch size must be greater than zero")
+	}
+
+	modelSchema, err := r.schema()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := modelSchema.LookUpField(deletedColumn)
+	if deleted == nil {
+		return 0, ErrNotSoftDeletable
+	}
+
+	// Records are soft deleted using the connection's clock so the cutoff must use the same clock
+	cutoff := r.connection.NowFunc().Add(-olderThan)
+	purged := int64(0)
+
+	for {
+		batch := make([]m, 0, batchSize)
+
+		result := r.tenanted(UsePrimary(r.connection).Unscoped()).
+			Where(clause.Lt{Column: currentColumn(deleted), Value: cutoff}).
+			Order(clause.OrderByColumn{Column: clause.PrimaryColumn, Desc: false, Reorder: false}).
+			Limit(batchSize).
+			Find(&batch)
+		if result.Error != nil {
+			return purged, errors.Wrap(result.Error, "unable to fetch records to purge")
+		}
+
+		if len(batch) == 0 {
+			return purged, nil
+		}
+
+		result = r.write("purge records", func() *gorm.DB { return r.connection.Unscoped().Delete(&batch) })
+		if result.Error != nil {
+			return purged, errors.Wrap(result.Error, "unable to purge records")
+		}
+
+		purged += result.RowsAffected
+
+		if len(batch) < batchSize {
+			return purged, nil
+		}
+	}
+}
+
+// trashed limit a query to deleted records when only deleted records were requested.
+func (r repository[m]) trashed(query *gorm.DB) *gorm.DB {
+	if !r.onlyDeleted {
+		return query
+	}
+
+	modelSchema, err := r.schema()
+	if err != nil {
+		return failed(query, err)
+	}
+
+	deleted := modelSchema.LookUpField(deletedColumn)
+	if deleted == nil {
+		return failed(query, ErrNotSoftDeletable)
+	}
+
+	return query.Where(clause.Neq{Column: currentColumn(deleted), Value: nil})
+}
+
+// restore a deleted record. If the model has soft deletable has one or has many children the record is restored in a
+// transaction along with children which were deleted at the same time as the record or later, children deleted before
+// the record were deleted separately so they stay deleted.
+func (r repository[m]) restore(model *m) error {
+	modelSchema, err := r.schema()
+	if err != nil {
+		return err
+	}
+
+	if modelSchema.LookUpField(deletedColumn) == nil {
+		return ErrNotSoftDeletable
+	}
+
+	if !cascades(modelSchema) {
+		result := r.write("restore record", func() *gorm.DB {
+			return r.tenanted(r.connection.Unscoped()).Model(model).Update(deletedColumn, nil)
+		})
+		if result.Error == nil && result.RowsAffected == 0 {
+			r.unaffected(result, model)
+		}
+
+		return result.Error
+	}
+
+	err = r.retry.do(r.connection.Statement.Context, r.connection, "restore record", func() error {
+		return r.connection.Transaction(func(transaction *gorm.DB) error {
+			tx := r
+			tx.connection = transaction
+
+			return tx.cascade(modelSchema, model)
+		})
+	})
+
+	return translate(err)
+}
+
+// cascade restore a deleted record and its children.
+func (r repository[m]) cascade(modelSchema *schema.Schema, model *m) error {
+	stored, err := r.stored(model)
+	if err != nil {
+		return err
+	}
+
+	result := r.tenanted(r.connection.Unscoped()).Model(model).Update(deletedColumn, nil)
+	if result.Error == nil && result.RowsAffected == 0 {
+		r.unaffected(result, model)
+	}
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// Nothing was restored if the record doesn't exist or isn't deleted
+	if stored == nil || result.RowsAffected == 0 {
+		return nil
+	}
+
+	ctx := r.connection.Statement.Context
+	reflected := reflect.ValueOf(stored).Elem()
+
+	value, _ := modelSchema.LookUpField(deletedColumn).ValueOf(ctx, reflected)
+
+	since, ok := deletedAt(value)
+	if !ok {
+		return nil
+	}
+
+	orm := UsePrimary(r.connection.Session(&gorm.Session{NewDB: true})) //nolint:exhaustruct // Default session
+
+	return r.restoreChildren(orm, modelSchema, reflected, since)
+}
+
+// cascades determine whether a model has soft deletable children which are restored with it.
+func cascades(modelSchema *schema.Schema) bool {
+	for _, relationship := range modelSchema.Relationships.Relations {
+		if restorable(modelSchema, relationship) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// restorable determine whether a relationship holds soft deletable children of a model. Relationships gorm registers
+// on behalf of other models are ignored.
+func restorable(modelSchema *schema.Schema, relationship *schema.Relationship) bool {
+	if relationship.Schema.ModelType != modelSchema.ModelType {
+		return false
+	}
+
+	if relationship.Type != schema.HasOne && relationship.Type != schema.HasMany {
+		return false
+	}
+
+	return relationship.FieldSchema.LookUpField(deletedColumn) != nil
+}
+
+// currentColumn the current table column for a field.
+func currentColumn(field *schema.Field) clause.Column {
+	return clause.Column{Alias: "", Name: field.DBName, Raw: false, Table: clause.CurrentTable}
+}
+
+// deletedAt read the time a record was deleted from a soft delete field value.
+func deletedAt(value any) (time.Time, bool) {
+	switch deleted := value.(type) {
+	case gorm.DeletedAt:
+		return deleted.Time, deleted.Valid
+	case *gorm.DeletedAt:
+		if deleted == nil {
+			return time.Time{}, false
+		}
+
+		return deleted.Time, deleted.Valid
+	case sql.NullTime:
+		return deleted.Time, deleted.Valid
+	case time.Time:
+		return deleted, !deleted.IsZero()
+	case *time.Time:
+		if deleted == nil {
+			return time.Time{}, false
+		}
+
+		return *deleted, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// restoreChildren restore children of a record which were deleted at or after deleted, recursing into their children.
+// Tenant scoped children are only restored if they belong to the tenant.
+func (r repository[m]) restoreChildren(orm *gorm.DB, parentSchema *schema.Schema, parent reflect.Value, deleted time.Time) error {
+	ctx := r.connection.Statement.Context
+
+	names := make([]string, 0, len(parentSchema.Relationships.Relations))
+	for name := range parentSchema.Relationships.Relations {
+		names = append(names, name)
+	}
+
+	// Restore relationships in a consistent order
+	sort.Strings(names)
+
+	for _, name := range names {
+		relationship := parentSchema.Relationships.Relations[name]
+		if !restorable(parentSchema, relationship) {
+			continue
+		}
+
+		scope, err := r.tenancyOf(relationship.FieldSchema)
+		if err != nil {
+			return err
+		}
+
+		column := currentColumn(relationship.FieldSchema.LookUpField(deletedColumn))
+
+		query := scope.apply(orm.Unscoped()).Where(clause.Gte{Column: column, Value: deleted})
+
+		for _, reference := range relationship.References {
+			if reference.OwnPrimaryKey {
+				value, _ := reference.PrimaryKey.ValueOf(ctx, parent)
+				query = query.Where(clause.Eq{Column: currentColumn(reference.ForeignKey), Value: value})
+
+				continue
+			}
+
+			// Polymorphic relationships also match on the type column
+			query = query.Where(clause.Eq{Column: currentColumn(reference.ForeignKey), Value: reference.PrimaryValue})
+		}
+
+		children := reflect.New(reflect.SliceOf(relationship.FieldSchema.ModelType))
+
+		result := query.Find(children.Interface())
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "unable to fetch deleted %s", name)
+		}
+
+		if children.Elem().Len() == 0 {
+			continue
+		}
+
+		result = orm.Unscoped().Model(children.Interface()).Update(deletedColumn, nil)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "unable to restore %s", name)
+		}
+
+		for index := range children.Elem().Len() {
+			err = r.restoreChildren(orm, relationship.FieldSchema, children.Elem().Index(index), deleted)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
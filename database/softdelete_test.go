@@ -0,0 +1,252 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
+)
+
+// commentMock soft deletable grandchild of a folder.
+type commentMock struct {
+	DeletedAt gorm.DeletedAt
+	FileID    int
+	ID        int
+}
+
+// fileMock soft deletable child of a folder.
+type fileMock struct {
+	Comments  []commentMock `gorm:"foreignKey:FileID"`
+	DeletedAt gorm.DeletedAt
+	FolderID  int
+	ID        int
+}
+
+// folderMock soft deletable model with children.
+type folderMock struct {
+	DeletedAt gorm.DeletedAt
+	Files     []fileMock `gorm:"foreignKey:FolderID"`
+	ID        int
+}
+
+// noteMock soft deletable model scoped to a tenant.
+type noteMock struct {
+	DeletedAt gorm.DeletedAt
+	ID        int
+	TenantID  string `gorm:"tenant"`
+}
+
+// tagMock model which can't be soft deleted.
+type tagMock struct {
+	ID   int
+	Name string
+}
+
+// TableName return the database table for this model.
+func (c commentMock) TableName() string {
+	return "comment_mocks"
+}
+
+// TableName return the database table for this model.
+func (f fileMock) TableName() string {
+	return "file_mocks"
+}
+
+// TableName return the database table for this model.
+func (f folderMock) TableName() string {
+	return "folder_mocks"
+}
+
+// TableName return the database table for this model.
+func (n noteMock) TableName() string {
+	return "note_mocks"
+}
+
+// TableName return the database table for this model.
+func (t tagMock) TableName() string {
+	return "tag_mocks"
+}
+
+func TestRepository_ForceDelete(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+	repository := database.Repository[modelmock.ModelMock](connection)
+
+	for range 2 {
+		require.NoError(t, repository.Create(&modelmock.ModelMock{}))
+	}
+
+	require.NoError(t, repository.ForceDelete(&modelmock.ModelMock{ID: 1}))
+
+	// Deleted records can also be removed permanently
+	require.NoError(t, repository.Delete(&modelmock.ModelMock{ID: 2}))
+	require.NoError(t, repository.ForceDelete(&modelmock.ModelMock{ID: 2}))
+
+	count, err := repository.BypassDelete().Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(0), count)
+}
+
+func TestRepository_OnlyDeleted(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+	require.NoError(t, connection.Migrate(tagMock{}))
+
+	repository := database.Repository[modelmock.ModelMock](connection)
+
+	for range 3 {
+		require.NoError(t, repository.Create(&modelmock.ModelMock{}))
+	}
+
+	require.NoError(t, repository.Delete(&modelmock.ModelMock{ID: 2}))
+
+	models, err := repository.OnlyDeleted().Get()
+	require.NoError(t, err)
+
+	require.Len(t, models, 1)
+	assert.Equal(t, 2, models[0].ID)
+
+	count, err := repository.OnlyDeleted().Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), count)
+
+	count, err = repository.Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), count)
+
+	// Only deleted records doesn't count as a condition for bulk writes
+	_, err = repository.OnlyDeleted().DeleteWhere(database.And())
+	require.ErrorIs(t, err, database.ErrMissingConditions)
+
+	_, err = database.Repository[tagMock](connection).OnlyDeleted().Get()
+	require.ErrorIs(t, err, database.ErrNotSoftDeletable)
+}
+
+func TestRepository_Purge(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+	require.NoError(t, connection.Migrate(tagMock{}))
+
+	repository := database.Repository[modelmock.ModelMock](connection)
+
+	for range 6 {
+		require.NoError(t, repository.Create(&modelmock.ModelMock{}))
+	}
+
+	_, err := repository.DeleteWhere(database.Lte("id", 5))
+	require.NoError(t, err)
+
+	// Only records deleted before the retention period are purged
+	_, err = repository.BypassDelete().UpdateWhere(map[string]any{"deleted_at": time.Now().Add(-48 * time.Hour)}, database.Lte("id", 4))
+	require.NoError(t, err)
+
+	purged, err := repository.Purge(24*time.Hour, 3)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(4), purged)
+
+	count, err := repository.BypassDelete().Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), count)
+
+	purged, err = repository.Purge(0, 10)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), purged)
+
+	// Purges are limited to the tenant
+	require.NoError(t, connection.Migrate(noteMock{}))
+
+	for _, tenant := range []string{"acme", "acme", "globex"} {
+		notes := database.Repository[noteMock](connection).WithContext(database.WithTenant(context.Background(), tenant))
+
+		note := &noteMock{}
+		require.NoError(t, notes.Create(note))
+		require.NoError(t, notes.Delete(note))
+	}
+
+	notes := database.Repository[noteMock](connection).WithContext(database.WithTenant(context.Background(), "acme"))
+
+	purged, err = notes.Purge(0, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), purged)
+
+	count, err = database.Repository[noteMock](connection).BypassTenant("test").OnlyDeleted().Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), count)
+
+	_, err = repository.Purge(time.Hour, 0)
+	require.EqualError(t, err, "batch size must be greater than zero")
+
+	_, err = database.Repository[tagMock](connection).Purge(time.Hour, 1)
+	require.ErrorIs(t, err, database.ErrNotSoftDeletable)
+}
+
+func TestRepository_Restore_Cascade(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+	require.NoError(t, connection.Migrate(folderMock{}, fileMock{}, commentMock{}, tagMock{}))
+
+	folders := database.Repository[folderMock](connection)
+	files := database.Repository[fileMock](connection)
+	comments := database.Repository[commentMock](connection)
+
+	folder := &folderMock{Files: []fileMock{{Comments: []commentMock{{}}}, {}, {}, {}}}
+	require.NoError(t, folders.Create(folder))
+
+	// Files deleted separately before the folder stay deleted
+	_, err := files.DeleteWhere(database.Eq("id", 3))
+	require.NoError(t, err)
+
+	_, err = files.BypassDelete().UpdateWhere(map[string]any{"deleted_at": time.Now().Add(-time.Hour)}, database.Eq("id", 3))
+	require.NoError(t, err)
+
+	// Children deleted after the folder were deleted with it, however long the cascade took
+	require.NoError(t, folders.Delete(&folderMock{ID: folder.ID}))
+
+	_, err = comments.DeleteWhere(database.Gt("id", 0))
+	require.NoError(t, err)
+
+	_, err = files.DeleteWhere(database.Gt("id", 0))
+	require.NoError(t, err)
+
+	_, err = files.BypassDelete().UpdateWhere(map[string]any{"deleted_at": time.Now().Add(time.Minute)}, database.Eq("id", 4))
+	require.NoError(t, err)
+
+	require.NoError(t, folders.Restore(&folderMock{ID: folder.ID}))
+
+	restored, err := folders.Then("Files.Comments").One(database.Eq("id", folder.ID))
+	require.NoError(t, err)
+
+	require.Len(t, restored.Files, 3)
+	assert.Equal(t, []int{1, 2, 4}, []int{restored.Files[0].ID, restored.Files[1].ID, restored.Files[2].ID})
+	assert.Len(t, restored.Files[0].Comments, 1)
+
+	deleted, err := files.OnlyDeleted().Get()
+	require.NoError(t, err)
+
+	require.Len(t, deleted, 1)
+	assert.Equal(t, 3, deleted[0].ID)
+
+	// Restoring a record which isn't deleted does nothing
+	require.NoError(t, folders.Restore(&folderMock{ID: folder.ID}))
+
+	err = database.Repository[tagMock](connection).Restore(&tagMock{ID: 1})
+	require.ErrorIs(t, err, database.ErrNotSoftDeletable)
+}
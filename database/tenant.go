@@ -0,0 +1,256 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/carlmjohnson/truthy"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// tenancy tenant filter applied to a tenant scoped model.
+type tenancy struct {
+	field  *schema.Field
+	tenant any
+}
+
+// tenantKey context key for tenants.
+type tenantKey struct{}
+
+var (
+	// ErrMissingTenant error returned when a tenant scoped model is used without a tenant in the context.
+	ErrMissingTenant = errors.New("tenant must be provided in the context for tenant scoped models")
+
+	// ErrTenantMismatch error returned when a model is written with a tenant other than the tenant in the context.
+	ErrTenantMismatch = errors.New("model belongs to a different tenant")
+)
+
+// Tenant return the tenant carried by ctx, nil is returned if there is no tenant.
+func Tenant(ctx context.Context) any {
+	if ctx == nil {
+		return nil
+	}
+
+	return ctx.Value(tenantKey{})
+}
+
+// WithTenant return a copy of ctx carrying a tenant. Models with a field tagged with gorm:"tenant" are tenant scoped,
+// repositories for these models only read and write records belonging to the tenant and set the tenant when records
+// are created. The tenant must be assignable to the tenant field.
+func WithTenant(ctx context.Context, tenant any) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// BypassTenant read and write records belonging to every tenant, for use by administrative tooling. A warning with
+// the reason and actor is logged every time the scope is bypassed.
+func (r repository[m]) BypassTenant(reason string) Persister[m] {
+	transaction := r
+	transaction.unscopedTenant = true
+
+	actor := Actor(r.connection.Statement.Context)
+
+	r.logger.Warn(
+		"tenant scope bypassed for %s by %s: %s",
+		r.model.TableName(),
+		truthy.Cond(actor == "", "unknown actor", actor),
+		reason,
+	)
+
+	return transaction
+}
+
+// assignTenant set the tenant of models being written.
+func (r repository[m]) assignTenant(models ...*m) error {
+	scope, err := r.tenancy()
+	if err != nil {
+		return err
+	}
+
+	for _, model := range models {
+		err = scope.assign(r.connection.Statement.Context, model)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tenancy determine the tenant filter for the model, nil is returned if the model isn't tenant scoped or the scope
+// was bypassed.
+func (r repository[m]) tenancy() (*tenancy, error) {
+	if r.unscopedTenant {
+		return nil, nil //nolint:nilnil // Bypassed scopes have no filter
+	}
+
+	modelSchema, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.tenancyOf(modelSchema)
+}
+
+// tenancyOf determine the tenant filter for records of a schema, such as related records, nil is returned if the
+// records aren't tenant scoped or the scope was bypassed.
+func (r repository[m]) tenancyOf(modelSchema *schema.Schema) (*tenancy, error) {
+	if r.unscopedTenant {
+		return nil, nil //nolint:nilnil // Bypassed scopes have no filter
+	}
+
+	field := tenantField(modelSchema)
+	if field == nil {
+		return nil, nil //nolint:nilnil // Models without a tenant field aren't scoped
+	}
+
+	tenant := Tenant(r.connection.Statement.Context)
+	if tenant == nil {
+		return nil, ErrMissingTenant
+	}
+
+	return &tenancy{field: field, tenant: tenant}, nil
+}
+
+// relationTenancy determine the tenant filter for each level of a relationship path such as Files.Comments, levels
+// which aren't tenant scoped have a nil filter. Unknown relationships are left for gorm to report.
+func (r repository[m]) relationTenancy(key string) ([]*tenancy, error) {
+	current, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+
+	segments := strings.Split(key, ".")
+	scopes := make([]*tenancy, 0, len(segments))
+
+	for _, segment := range segments {
+		relationship, ok := current.Relationships.Relations[segment]
+		if !ok {
+			break
+		}
+
+		scope, err := r.tenancyOf(relationship.FieldSchema)
+		if err != nil {
+			return nil, err
+		}
+
+		scopes = append(scopes, scope)
+		current = relationship.FieldSchema
+	}
+
+	return scopes, nil
+}
+
+// tenanted apply the tenant filter to a query, the query fails if the tenant filter can't be determined.
+func (r repository[m]) tenanted(query *gorm.DB) *gorm.DB {
+	scope, err := r.tenancy()
+	if err != nil {
+		return failed(query, err)
+	}
+
+	return scope.apply(query)
+}
+
+// visible determine whether the record for a model exists and belongs to the tenant, including deleted records.
+func (r repository[m]) visible(model *m) (bool, error) {
+	modelSchema, err := r.schema()
+	if err != nil {
+		return false, err
+	}
+
+	reflected := reflect.ValueOf(model).Elem()
+	where := make([]any, 0, len(modelSchema.PrimaryFields))
+
+	for _, field := range modelSchema.PrimaryFields {
+		value, _ := field.ValueOf(r.connection.Statement.Context, reflected)
+
+		where = append(where, clause.Eq{
+			Column: clause.Column{Alias: "", Name: field.DBName, Raw: false, Table: clause.CurrentTable},
+			Value:  value,
+		})
+	}
+
+	check := r
+	check.unscoped = true
+
+	return check.Exists(where...)
+}
+
+// unaffected add ErrNoResults to a tenant scoped write which didn't affect any rows if the record doesn't belong to the
+// tenant, so hooks, audit entries and changes aren't produced for a write which didn't happen.
+func (r repository[m]) unaffected(result *gorm.DB, model *m) {
+	scope, err := r.tenancy()
+	if err != nil {
+		_ = result.AddError(err)
+
+		return
+	}
+
+	if scope != nil {
+		r.missing(result, false, model)
+	}
+}
+
+// apply add the tenant filter to a query.
+func (t *tenancy) apply(query *gorm.DB) *gorm.DB {
+	if t == nil {
+		return query
+	}
+
+	return query.Where(t.condition(""))
+}
+
+// condition the tenant filter for a table, an empty table uses the current table.
+func (t *tenancy) condition(table string) clause.Expression {
+	return clause.Eq{
+		Column: clause.Column{Alias: "", Name: t.field.DBName, Raw: false, Table: truthy.Cond(table == "", clause.CurrentTable, table)},
+		Value:  t.tenant,
+	}
+}
+
+// assign set the tenant of a model, models which already belong to another tenant are rejected.
+func (t *tenancy) assign(ctx context.Context, model any) error {
+	if t == nil {
+		return nil
+	}
+
+	reflected := reflect.Indirect(reflect.ValueOf(model))
+
+	value, zero := t.field.ValueOf(ctx, reflected)
+	if !zero {
+		// Compare formatted values so tenants of different integer types match
+		if fmt.Sprint(reflect.Indirect(reflect.ValueOf(value)).Interface()) == fmt.Sprint(t.tenant) {
+			return nil
+		}
+
+		return ErrTenantMismatch
+	}
+
+	err := t.field.Set(ctx, reflected, t.tenant)
+	if err != nil {
+		return errors.Wrap(err, "unable to set tenant")
+	}
+
+	return nil
+}
+
+// tenantField find the field tagged with gorm:"tenant", nil is returned if the model isn't tenant scoped. Tenancy is
+// opt in so existing models with a tenant column keep working without a tenant in the context.
+func tenantField(modelSchema *schema.Schema) *schema.Field {
+	for _, field := range modelSchema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+
+		if _, tagged := field.TagSettings["TENANT"]; tagged {
+			return field
+		}
+	}
+
+	return nil
+}
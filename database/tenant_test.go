@@ -0,0 +1,474 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/sjdaws/pkg/database"
+	"github.com/sjdaws/pkg/testing/logging/logmock"
+)
+
+// accountMock model scoped to a tenant by a tagged field.
+type accountMock struct {
+	ID           int
+	Name         string
+	Organisation int `gorm:"tenant"`
+}
+
+// invoiceMock model scoped to a tenant by a tagged tenant_id column.
+type invoiceMock struct {
+	ID       int
+	Paid     bool
+	TenantID string `gorm:"tenant"`
+}
+
+// ledgerMock model with a tenant_id column which isn't tenant scoped.
+type ledgerMock struct {
+	ID       int
+	TenantID string
+}
+
+// memberMock model scoped to a tenant with a unique column.
+type memberMock struct {
+	Email    string `gorm:"uniqueIndex"`
+	ID       int
+	Name     string
+	TenantID string `gorm:"tenant"`
+}
+
+// leadMock has one relationship of a project scoped to a tenant.
+type leadMock struct {
+	ID        int
+	Name      string
+	ProjectID int
+	TenantID  string `gorm:"tenant"`
+}
+
+// projectMock soft deletable model scoped to a tenant with tenant scoped relationships.
+type projectMock struct {
+	DeletedAt gorm.DeletedAt
+	ID        int
+	Lead      leadMock   `gorm:"foreignKey:ProjectID"`
+	Tasks     []taskMock `gorm:"foreignKey:ProjectID"`
+	TenantID  string     `gorm:"tenant"`
+}
+
+// taskMock soft deletable has many relationship of a project scoped to a tenant.
+type taskMock struct {
+	DeletedAt gorm.DeletedAt
+	ID        int
+	ProjectID int
+	TenantID  string `gorm:"tenant"`
+}
+
+// TableName return the database table for this model.
+func (a accountMock) TableName() string {
+	return "account_mocks"
+}
+
+// TableName return the database table for this model.
+func (i invoiceMock) TableName() string {
+	return "invoice_mocks"
+}
+
+// TableName return the database table for this model.
+func (l ledgerMock) TableName() string {
+	return "ledger_mocks"
+}
+
+// TableName return the database table for this model.
+func (m memberMock) TableName() string {
+	return "member_mocks"
+}
+
+// TableName return the database table for this model.
+func (l leadMock) TableName() string {
+	return "lead_mocks"
+}
+
+// TableName return the database table for this model.
+func (p projectMock) TableName() string {
+	return "project_mocks"
+}
+
+// TableName return the database table for this model.
+func (t taskMock) TableName() string {
+	return "task_mocks"
+}
+
+func TestTenant(t *testing.T) {
+	t.Parallel()
+
+	ctx := database.WithTenant(context.Background(), "acme")
+
+	assert.Equal(t, "acme", database.Tenant(ctx))
+	assert.Nil(t, database.Tenant(context.Background()))
+	assert.Nil(t, database.Tenant(nil)) //nolint:staticcheck // Testing a nil context
+}
+
+func TestRepository_BypassTenant(t *testing.T) {
+	t.Parallel()
+
+	log := logmock.New()
+	connection := connectTenant(t, log)
+
+	ctx := database.WithActor(database.WithTenant(context.Background(), "acme"), "admin:1")
+	repository := database.Repository[invoiceMock](connection).WithContext(ctx)
+
+	count, err := repository.BypassTenant("support ticket 42").Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(4), count)
+	assert.Equal(t, "warn", log.GetLastLevel())
+	assert.Equal(t, "tenant scope bypassed for invoice_mocks by admin:1: support ticket 42", log.GetLastMessage())
+
+	// Bypassing doesn't require a tenant
+	updated, err := database.Repository[invoiceMock](connection).
+		BypassTenant("nightly reconciliation").
+		UpdateWhere(map[string]any{"paid": true}, database.Eq("paid", false))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(4), updated)
+	assert.Equal(t, "tenant scope bypassed for invoice_mocks by unknown actor: nightly reconciliation", log.GetLastMessage())
+}
+
+func TestRepository_Tenant(t *testing.T) {
+	t.Parallel()
+
+	connection := connectTenant(t, logmock.New())
+	acme := database.Repository[invoiceMock](connection).WithContext(database.WithTenant(context.Background(), "acme"))
+	globex := database.Repository[invoiceMock](connection).WithContext(database.WithTenant(context.Background(), "globex"))
+
+	invoices, err := acme.Get()
+	require.NoError(t, err)
+
+	assert.Equal(t, []invoiceMock{{ID: 1, TenantID: "acme"}, {ID: 3, TenantID: "acme"}}, invoices)
+
+	count, err := globex.Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), count)
+
+	// Records belonging to another tenant can't be read
+	_, err = acme.One(database.Eq("id", 2))
+	require.ErrorIs(t, err, database.ErrNoResults)
+
+	// Records belonging to another tenant can't be written
+	err = acme.Update(&invoiceMock{ID: 2, Paid: true})
+	require.ErrorIs(t, err, database.ErrNoResults)
+
+	err = acme.Update(&invoiceMock{ID: 2, Paid: true, TenantID: "globex"})
+	require.ErrorIs(t, err, database.ErrTenantMismatch)
+
+	err = acme.UpdateFields(&invoiceMock{ID: 4, Paid: true}, "Paid")
+	require.ErrorIs(t, err, database.ErrNoResults)
+
+	// The tenant filter doesn't count as a condition for bulk writes
+	_, err = acme.DeleteWhere(map[string]any{})
+	require.ErrorIs(t, err, database.ErrMissingConditions)
+
+	_, err = acme.UpdateWhere(map[string]any{"paid": true}, database.Or{})
+	require.ErrorIs(t, err, database.ErrMissingConditions)
+
+	err = acme.Delete(&invoiceMock{ID: 2})
+	require.ErrorIs(t, err, database.ErrNoResults)
+
+	deleted, err := acme.DeleteWhere(database.Gt("id", 0))
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), deleted)
+
+	invoices, err = globex.Get()
+	require.NoError(t, err)
+
+	assert.Equal(t, []invoiceMock{{ID: 2, TenantID: "globex"}, {ID: 4, TenantID: "globex"}}, invoices)
+
+	// Updates within the tenant succeed even if nothing changed
+	require.NoError(t, globex.Update(&invoices[0]))
+
+	invoices[1].Paid = true
+	require.NoError(t, globex.Update(&invoices[1]))
+
+	paid, err := globex.Exists(database.Eq("paid", true))
+	require.NoError(t, err)
+
+	assert.True(t, paid)
+}
+
+func TestRepository_Tenant_OptIn(t *testing.T) {
+	t.Parallel()
+
+	connection := connectTenant(t, logmock.New())
+	require.NoError(t, connection.Migrate(ledgerMock{}))
+
+	// Models are only tenant scoped if the tenant field is tagged
+	repository := database.Repository[ledgerMock](connection)
+
+	require.NoError(t, repository.Create(&ledgerMock{TenantID: "acme"}))
+	require.NoError(t, repository.Create(&ledgerMock{TenantID: "globex"}))
+
+	count, err := repository.WithContext(database.WithTenant(context.Background(), "acme")).Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), count)
+
+	ledger := &ledgerMock{ID: 1, TenantID: "initech"}
+	require.NoError(t, repository.Update(ledger))
+
+	updated, err := repository.One(database.Eq("id", 1))
+	require.NoError(t, err)
+
+	assert.Equal(t, "initech", updated.TenantID)
+}
+
+func TestRepository_Tenant_Create(t *testing.T) {
+	t.Parallel()
+
+	connection := connectTenant(t, logmock.New())
+	require.NoError(t, connection.Migrate(accountMock{}))
+
+	ctx := database.WithTenant(context.Background(), 7)
+	repository := database.Repository[accountMock](connection).WithContext(ctx)
+
+	account := &accountMock{Name: "first"}
+	require.NoError(t, repository.Create(account))
+
+	assert.Equal(t, 7, account.Organisation)
+
+	accounts := []accountMock{{Name: "second"}, {Name: "third", Organisation: 7}}
+	require.NoError(t, repository.CreateMany(accounts, 0))
+
+	assert.Equal(t, 7, accounts[0].Organisation)
+
+	err := repository.Create(&accountMock{Name: "fourth", Organisation: 8})
+	require.ErrorIs(t, err, database.ErrTenantMismatch)
+
+	count, err := repository.Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), count)
+
+	// Tenant scoped models can't be used without a tenant
+	err = database.Repository[accountMock](connection).Create(&accountMock{Name: "fifth"})
+	require.ErrorIs(t, err, database.ErrMissingTenant)
+
+	_, err = database.Repository[accountMock](connection).Get()
+	require.ErrorIs(t, err, database.ErrMissingTenant)
+}
+
+func TestRepository_Tenant_Upsert(t *testing.T) {
+	t.Parallel()
+
+	connection := connectTenant(t, logmock.New())
+	require.NoError(t, connection.Migrate(memberMock{}))
+
+	acme := database.Repository[memberMock](connection).WithContext(database.WithTenant(context.Background(), "acme"))
+	globex := database.Repository[memberMock](connection).WithContext(database.WithTenant(context.Background(), "globex"))
+
+	require.NoError(t, globex.Create(&memberMock{Email: "x@y", Name: "original"}))
+
+	// Records belonging to another tenant can't be taken over by an upsert
+	err := acme.Upsert(&memberMock{Email: "x@y", Name: "hijacked"}, []string{"Email"}, nil)
+	require.ErrorIs(t, err, database.ErrTenantMismatch)
+
+	err = acme.Upsert(&memberMock{Email: "x@y", Name: "hijacked", TenantID: "acme"}, []string{"Email"}, []string{"Name", "TenantID"})
+	require.ErrorIs(t, err, database.ErrTenantMismatch)
+
+	members, err := database.Repository[memberMock](connection).BypassTenant("test").Get()
+	require.NoError(t, err)
+
+	assert.Equal(t, []memberMock{{Email: "x@y", ID: 1, Name: "original", TenantID: "globex"}}, members)
+
+	// Records belonging to the tenant are created and updated as usual
+	created := &memberMock{Email: "a@b", Name: "created"}
+	require.NoError(t, acme.Upsert(created, []string{"Email"}, nil))
+	require.NoError(t, globex.Upsert(&memberMock{Email: "x@y", Name: "updated"}, []string{"Email"}, nil))
+
+	members, err = database.Repository[memberMock](connection).BypassTenant("test").Get()
+	require.NoError(t, err)
+
+	assert.Equal(t, []memberMock{
+		{Email: "x@y", ID: 1, Name: "updated", TenantID: "globex"},
+		{Email: "a@b", ID: created.ID, Name: "created", TenantID: "acme"},
+	}, members)
+}
+
+func TestRepository_Tenant_Delete(t *testing.T) {
+	t.Parallel()
+
+	connection := connectAudit(t, "")
+	require.NoError(t, connection.Migrate(noteMock{}, projectMock{}, leadMock{}, taskMock{}))
+
+	acme := database.Repository[noteMock](connection).WithContext(database.WithTenant(context.Background(), "acme"))
+	globex := database.Repository[noteMock](connection).WithContext(database.WithTenant(context.Background(), "globex"))
+
+	events := make([]database.Event, 0)
+
+	for _, event := range []database.Event{database.AfterDelete, database.AfterRestore} {
+		globex.Hook(event, func(_ context.Context, _ *noteMock) error {
+			events = append(events, event)
+
+			return nil
+		})
+	}
+
+	changes := make([]database.Change[noteMock], 0)
+
+	stop := globex.Subscribe(func(_ context.Context, change database.Change[noteMock]) {
+		changes = append(changes, change)
+	})
+	defer stop()
+
+	note := &noteMock{}
+	require.NoError(t, acme.Create(note))
+
+	// Records belonging to another tenant can't be deleted or restored
+	err := globex.Delete(&noteMock{ID: note.ID})
+	require.ErrorIs(t, err, database.ErrNoResults)
+
+	err = globex.ForceDelete(&noteMock{ID: note.ID})
+	require.ErrorIs(t, err, database.ErrNoResults)
+
+	require.NoError(t, acme.Delete(note))
+
+	err = globex.Restore(&noteMock{ID: note.ID})
+	require.ErrorIs(t, err, database.ErrNoResults)
+
+	// Writes which didn't happen don't run hooks, publish changes or write audit entries
+	assert.Equal(t, []database.Event{database.AfterDelete}, events)
+	require.Len(t, changes, 2)
+	assert.Equal(t, database.AfterCreate, changes[0].Event)
+	assert.Equal(t, database.AfterDelete, changes[1].Event)
+
+	history, err := acme.History(note)
+	require.NoError(t, err)
+
+	require.Len(t, history, 2)
+	assert.Equal(t, database.AuditCreate, history[0].Action)
+	assert.Equal(t, database.AuditDelete, history[1].Action)
+
+	count, err := acme.OnlyDeleted().Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), count)
+
+	// Records with children are restored in a transaction which is also limited to the tenant
+	projects := database.Repository[projectMock](connection).WithContext(database.WithTenant(context.Background(), "acme"))
+
+	project := &projectMock{Tasks: []taskMock{{}}}
+	require.NoError(t, projects.Create(project))
+	require.NoError(t, projects.Delete(project))
+
+	err = database.Repository[projectMock](connection).
+		WithContext(database.WithTenant(context.Background(), "globex")).
+		Restore(&projectMock{ID: project.ID})
+	require.ErrorIs(t, err, database.ErrNoResults)
+
+	count, err = projects.OnlyDeleted().Count()
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), count)
+
+	// Deleting a record which is already deleted within the tenant still succeeds
+	require.NoError(t, acme.Delete(note))
+}
+
+func TestRepository_Tenant_History(t *testing.T) {
+	t.Parallel()
+
+	connection := connectAudit(t, "")
+	require.NoError(t, connection.Migrate(invoiceMock{}))
+
+	acme := database.Repository[invoiceMock](connection).WithContext(database.WithTenant(context.Background(), "acme"))
+	globex := database.Repository[invoiceMock](connection).WithContext(database.WithTenant(context.Background(), "globex"))
+
+	invoice := &invoiceMock{}
+	require.NoError(t, acme.Create(invoice))
+
+	history, err := acme.History(invoice)
+	require.NoError(t, err)
+
+	assert.Len(t, history, 1)
+
+	// History of records belonging to another tenant can't be read
+	_, err = globex.History(invoice)
+	require.ErrorIs(t, err, database.ErrNoResults)
+}
+
+func TestRepository_Tenant_Relations(t *testing.T) {
+	t.Parallel()
+
+	connection := connectTenant(t, logmock.New())
+	require.NoError(t, connection.Migrate(projectMock{}, leadMock{}, taskMock{}))
+
+	acme := database.Repository[projectMock](connection).WithContext(database.WithTenant(context.Background(), "acme"))
+
+	require.NoError(t, acme.Create(&projectMock{}))
+	require.NoError(t, acme.Create(&projectMock{}))
+
+	// Related records belonging to another tenant share the project's foreign key
+	related := []any{
+		&leadMock{Name: "other", ProjectID: 1, TenantID: "globex"},
+		&leadMock{Name: "own", ProjectID: 2, TenantID: "acme"},
+		&taskMock{ProjectID: 1, TenantID: "acme"},
+		&taskMock{ProjectID: 1, TenantID: "globex"},
+	}
+
+	for _, model := range related {
+		require.NoError(t, connection.ORM().Create(model).Error)
+	}
+
+	project, err := acme.Then("Tasks").One(database.Eq("id", 1))
+	require.NoError(t, err)
+
+	require.Len(t, project.Tasks, 1)
+	assert.Equal(t, "acme", project.Tasks[0].TenantID)
+
+	projects, err := acme.With("Lead").Get()
+	require.NoError(t, err)
+
+	require.Len(t, projects, 1)
+	assert.Equal(t, "own", projects[0].Lead.Name)
+
+	// Children are only restored with their parent if they belong to the tenant
+	require.NoError(t, acme.Delete(&projectMock{ID: 1}))
+
+	_, err = database.Repository[taskMock](connection).BypassTenant("test").DeleteWhere(database.Eq("project_id", 1))
+	require.NoError(t, err)
+
+	require.NoError(t, acme.Restore(&projectMock{ID: 1}))
+
+	deleted, err := database.Repository[taskMock](connection).BypassTenant("test").OnlyDeleted().Get()
+	require.NoError(t, err)
+
+	require.Len(t, deleted, 1)
+	assert.Equal(t, "globex", deleted[0].TenantID)
+}
+
+// connectTenant create a database with invoices for two tenants.
+func connectTenant(t *testing.T, log *logmock.LogMock) *database.Database {
+	t.Helper()
+
+	connection, err := database.Connect(database.Config{Driver: "sqlite", Logger: log, Name: t.TempDir() + "/tenant.db"})
+	require.NoError(t, err)
+
+	require.NoError(t, connection.Migrate(invoiceMock{}))
+
+	for index := range 4 {
+		tenant := "acme"
+		if index%2 == 1 {
+			tenant = "globex"
+		}
+
+		err = database.Repository[invoiceMock](connection).
+			WithContext(database.WithTenant(context.Background(), tenant)).
+			Create(&invoiceMock{})
+		require.NoError(t, err)
+	}
+
+	return connection
+}
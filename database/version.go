@@ -0,0 +1,158 @@
+package database
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+
+	"github.com/sjdaws/pkg/errors"
+)
+
+// ErrConflict error returned when a versioned record was changed or deleted after it was read.
+var ErrConflict = errors.New("record was changed by someone else")
+
+// save write a model to its record, Update creates the record if the model has no primary key value. Models are
+// versioned by an integer field tagged with gorm:"version", versioned records are only updated if the stored version
+// matches the model and every update increments the version. Tenant scoped records are only updated if they belong to
+// the tenant. If columns are provided only those columns and the version are updated.
+func (r repository[m]) save(model *m, columns ...string) *gorm.DB {
+	modelSchema, err := r.schema()
+	if err != nil {
+		return failed(r.connection, err)
+	}
+
+	field, err := versionField(modelSchema)
+	if err != nil {
+		return failed(r.connection, err)
+	}
+
+	scope, err := r.tenancy()
+	if err != nil {
+		return failed(r.connection, err)
+	}
+
+	ctx := r.connection.Statement.Context
+
+	err = scope.assign(ctx, model)
+	if err != nil {
+		return failed(r.connection, err)
+	}
+
+	if _, stored := recordKey(ctx, modelSchema, model); (field == nil && scope == nil) || !stored {
+		if len(columns) > 0 {
+			return r.connection.Model(model).Select(columns).Updates(model)
+		}
+
+		return r.connection.Save(model)
+	}
+
+	query := scope.apply(r.connection).Model(model)
+	reflected := reflect.ValueOf(model).Elem()
+
+	var current int64
+
+	if field != nil {
+		value, _ := field.ValueOf(ctx, reflected)
+		current = integer(value)
+
+		err = field.Set(ctx, reflected, current+1)
+		if err != nil {
+			return failed(r.connection, errors.Wrap(err, "unable to increment version"))
+		}
+
+		query = query.Where(clause.Eq{
+			Column: clause.Column{Alias: "", Name: field.DBName, Raw: false, Table: clause.CurrentTable},
+			Value:  current,
+		})
+
+		if len(columns) > 0 {
+			columns = append(columns, field.DBName)
+		}
+	}
+
+	if len(columns) > 0 {
+		query = query.Select(columns)
+	} else {
+		query = query.Select("*")
+	}
+
+	result := query.Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		r.missing(result, field != nil, model)
+	}
+
+	// Leave the model untouched if the update didn't happen, so it can be retried or refreshed
+	if result.Error != nil && field != nil {
+		_ = field.Set(ctx, reflected, current)
+	}
+
+	return result
+}
+
+// missing add an error to an update which didn't affect any rows. Versioned records conflict, otherwise the record
+// must not exist for the tenant since some drivers don't count rows which were matched but unchanged.
+func (r repository[m]) missing(result *gorm.DB, versioned bool, model *m) {
+	if versioned {
+		_ = result.AddError(ErrConflict)
+
+		return
+	}
+
+	found, err := r.visible(model)
+	if err != nil {
+		_ = result.AddError(err)
+
+		return
+	}
+
+	if !found {
+		_ = result.AddError(ErrNoResults)
+	}
+}
+
+// integer convert a version value to an integer.
+func integer(value any) int64 {
+	reflected := reflect.Indirect(reflect.ValueOf(value))
+
+	switch reflected.Kind() { //nolint:exhaustive // Version fields are always integers
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflected.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(reflected.Uint()) //nolint:gosec // Versions never approach the overflow
+	default:
+		return 0
+	}
+}
+
+// versionField find the field tagged with gorm:"version", nil is returned if the model isn't versioned. Versioning is
+// opt in so existing version columns aren't changed by updates, tagged fields must be integers.
+func versionField(modelSchema *schema.Schema) (*schema.Field, error) {
+	for _, field := range modelSchema.Fields {
+		if field.DBName == "" {
+			continue
+		}
+
+		if _, tagged := field.TagSettings["VERSION"]; tagged {
+			if !isInteger(field) {
+				return nil, errors.New("version field must be an integer: %s", field.Name)
+			}
+
+			return field, nil
+		}
+	}
+
+	return nil, nil //nolint:nilnil // Models without a tagged field aren't versioned
+}
+
+// isInteger determine whether a field holds an integer.
+func isInteger(field *schema.Field) bool {
+	switch field.IndirectFieldType.Kind() { //nolint:exhaustive // Only integer kinds are relevant
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
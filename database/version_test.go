@@ -0,0 +1,182 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sjdaws/pkg/database"
+)
+
+// documentMock model versioned by a tagged version column.
+type documentMock struct {
+	ID      int
+	Title   string
+	Version int `gorm:"version"`
+}
+
+// releaseMock model with an untagged version column.
+type releaseMock struct {
+	ID      int
+	Title   string
+	Version int
+}
+
+// revisionMock model versioned by a tagged field.
+type revisionMock struct {
+	ID       int
+	Revision uint `gorm:"version"`
+	Title    string
+}
+
+// invalidRevisionMock model with a tagged version field which isn't an integer.
+type invalidRevisionMock struct {
+	ID       int
+	Revision string `gorm:"version"`
+}
+
+// TableName return the database table for this model.
+func (d documentMock) TableName() string {
+	return "document_mocks"
+}
+
+// TableName return the database table for this model.
+func (i invalidRevisionMock) TableName() string {
+	return "invalid_revision_mocks"
+}
+
+// TableName return the database table for this model.
+func (r releaseMock) TableName() string {
+	return "release_mocks"
+}
+
+// TableName return the database table for this model.
+func (r revisionMock) TableName() string {
+	return "revision_mocks"
+}
+
+func TestRepository_Update_Version(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+	require.NoError(t, connection.Migrate(documentMock{}))
+
+	repository := database.Repository[documentMock](connection)
+
+	// Models without a primary key value are created
+	document := &documentMock{Title: "draft", Version: 1}
+	require.NoError(t, repository.Update(document))
+
+	stale := *document
+
+	document.Title = "first"
+	require.NoError(t, repository.Update(document))
+
+	assert.Equal(t, 2, document.Version)
+
+	stale.Title = "second"
+
+	err := repository.Update(&stale)
+	require.ErrorIs(t, err, database.ErrConflict)
+
+	require.EqualError(t, err, "unable to update record: record was changed by someone else")
+	assert.Equal(t, 1, stale.Version)
+
+	stored, err := repository.One(database.Eq("id", document.ID))
+	require.NoError(t, err)
+
+	assert.Equal(t, documentMock{ID: document.ID, Title: "first", Version: 2}, *stored)
+
+	// Zero values are written like any other update
+	document.Title = ""
+	require.NoError(t, repository.Update(document))
+
+	stored, err = repository.One(database.Eq("id", document.ID))
+	require.NoError(t, err)
+
+	assert.Equal(t, documentMock{ID: document.ID, Title: "", Version: 3}, *stored)
+
+	// Records deleted by someone else also conflict
+	_, err = repository.DeleteWhere(database.Eq("id", document.ID))
+	require.NoError(t, err)
+
+	err = repository.Update(document)
+	require.ErrorIs(t, err, database.ErrConflict)
+}
+
+func TestRepository_Update_VersionTag(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+	require.NoError(t, connection.Migrate(revisionMock{}, invalidRevisionMock{}, releaseMock{}))
+
+	repository := database.Repository[revisionMock](connection)
+
+	revision := &revisionMock{Title: "draft"}
+	require.NoError(t, repository.Create(revision))
+
+	stale := *revision
+
+	require.NoError(t, repository.Update(revision))
+	require.NoError(t, repository.Update(revision))
+
+	assert.Equal(t, uint(2), revision.Revision)
+
+	err := repository.Update(&stale)
+	require.ErrorIs(t, err, database.ErrConflict)
+
+	// Tagged version fields must be integers
+	err = database.Repository[invalidRevisionMock](connection).Update(&invalidRevisionMock{ID: 1})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "unable to update record: version field must be an integer: Revision")
+
+	// Version columns which aren't tagged don't version the model
+	release := &releaseMock{Title: "draft", Version: 3}
+	require.NoError(t, database.Repository[releaseMock](connection).Create(release))
+
+	previous := *release
+
+	release.Title = "final"
+	require.NoError(t, database.Repository[releaseMock](connection).Update(release))
+	require.NoError(t, database.Repository[releaseMock](connection).Update(&previous))
+
+	assert.Equal(t, 3, release.Version)
+
+	stored, err := database.Repository[releaseMock](connection).One(database.Eq("id", release.ID))
+	require.NoError(t, err)
+
+	assert.Equal(t, releaseMock{ID: release.ID, Title: "draft", Version: 3}, *stored)
+}
+
+func TestRepository_UpdateFields_Version(t *testing.T) {
+	t.Parallel()
+
+	connection := connectFile(t)
+	require.NoError(t, connection.Migrate(documentMock{}))
+
+	repository := database.Repository[documentMock](connection)
+
+	document := &documentMock{Title: "draft"}
+	require.NoError(t, repository.Create(document))
+
+	stale := *document
+
+	document.Title = "first"
+	require.NoError(t, repository.UpdateFields(document, "Title"))
+
+	assert.Equal(t, 1, document.Version)
+
+	stale.Title = "second"
+
+	err := repository.UpdateFields(&stale, "Title")
+	require.ErrorIs(t, err, database.ErrConflict)
+
+	assert.Equal(t, 0, stale.Version)
+
+	stored, err := repository.One(database.Eq("id", document.ID))
+	require.NoError(t, err)
+
+	assert.Equal(t, documentMock{ID: document.ID, Title: "first", Version: 1}, *stored)
+}
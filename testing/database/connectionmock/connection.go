@@ -1,6 +1,8 @@
 package connectionmock
 
 import (
+	"context"
+	"database/sql"
 	"os"
 	"testing"
 
@@ -8,14 +10,16 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
+	"github.com/sjdaws/pkg/database"
 	"github.com/sjdaws/pkg/database/drivers"
 	"github.com/sjdaws/pkg/errors"
 )
 
 // DatabaseMock mock database instance.
 type DatabaseMock struct {
-	Fail bool
-	orm  *gorm.DB
+	Fail  bool
+	hooks *database.Hooks
+	orm   *gorm.DB
 }
 
 // Options various settings which can be toggled when creating a mock connection.
@@ -87,14 +91,49 @@ func New(t *testing.T, options ...Options) *DatabaseMock {
 	require.NoError(t, err)
 
 	return &DatabaseMock{
-		Fail: fail,
-		orm:  orm,
+		Fail:  fail,
+		hooks: database.NewHooks(),
+		orm:   orm,
 	}
 }
 
+// Close the database connection.
+func (d *DatabaseMock) Close() error {
+	if d.Fail {
+		return errors.New("close failed")
+	}
+
+	pool, err := d.orm.DB()
+	if err != nil {
+		return errors.Wrap(err, "close failed")
+	}
+
+	err = pool.Close()
+	if err != nil {
+		return errors.Wrap(err, "close failed")
+	}
+
+	return nil
+}
+
+// Hooks return the hooks shared by every repository created from this connection.
+func (d *DatabaseMock) Hooks() *database.Hooks {
+	return d.hooks
+}
+
+// Metrics return no metrics.
+func (d *DatabaseMock) Metrics() []database.Metric {
+	return []database.Metric{}
+}
+
 // Migrate perform database migrations.
 func (d *DatabaseMock) Migrate(model ...any) error {
-	err := d.orm.AutoMigrate(model...)
+	return d.MigrateContext(context.Background(), model...)
+}
+
+// MigrateContext perform database migrations using a context.
+func (d *DatabaseMock) MigrateContext(ctx context.Context, model ...any) error {
+	err := d.orm.WithContext(ctx).AutoMigrate(model...)
 	if err != nil || d.Fail {
 		return errors.Wrap(err, "migration failed")
 	}
@@ -107,6 +146,44 @@ func (d *DatabaseMock) ORM() *gorm.DB {
 	return d.orm
 }
 
+// Ping the database connection.
+func (d *DatabaseMock) Ping(ctx context.Context) error {
+	if d.Fail {
+		return errors.New("ping failed")
+	}
+
+	pool, err := d.orm.DB()
+	if err != nil {
+		return errors.Wrap(err, "ping failed")
+	}
+
+	err = pool.PingContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "ping failed")
+	}
+
+	return nil
+}
+
+// Plan return an empty migration plan.
+func (d *DatabaseMock) Plan(_ context.Context, _ ...any) (*database.Plan, error) {
+	if d.Fail {
+		return nil, errors.New("plan failed")
+	}
+
+	return &database.Plan{Driver: d.orm.Name(), Statements: make([]string, 0)}, nil
+}
+
+// Stats return connection pool statistics.
+func (d *DatabaseMock) Stats() sql.DBStats {
+	pool, err := d.orm.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+
+	return pool.Stats()
+}
+
 // Transaction create a new database transaction.
 func (d *DatabaseMock) Transaction() *gorm.DB {
 	transaction := d.orm.Begin()
@@ -117,3 +194,14 @@ func (d *DatabaseMock) Transaction() *gorm.DB {
 
 	return transaction
 }
+
+// WithTransaction run fn within a database transaction.
+func (d *DatabaseMock) WithTransaction(ctx context.Context, fn func(tx database.Connection) error) error {
+	if d.Fail {
+		return errors.New("transaction failed")
+	}
+
+	return d.orm.WithContext(ctx).Transaction(func(transaction *gorm.DB) error {
+		return fn(&DatabaseMock{Fail: d.Fail, hooks: d.hooks, orm: transaction})
+	})
+}
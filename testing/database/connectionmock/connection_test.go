@@ -1,6 +1,7 @@
 package connectionmock_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/sjdaws/pkg/database"
 	"github.com/sjdaws/pkg/testing/database/connectionmock"
+	"github.com/sjdaws/pkg/testing/database/modelmock"
 )
 
 func TestNew(t *testing.T) {
@@ -35,6 +37,50 @@ func TestNew_Options(t *testing.T) {
 	assert.True(t, connection.Fail)
 }
 
+func TestConnection_Close(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t)
+
+	err := connection.Close()
+	require.NoError(t, err)
+
+	err = connection.Ping(context.Background())
+	require.Error(t, err)
+
+	require.EqualError(t, err, "ping failed: sql: database is closed")
+}
+
+func TestConnection_Close_Error(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t)
+	connection.Fail = true
+
+	err := connection.Close()
+	require.Error(t, err)
+
+	require.EqualError(t, err, "close failed")
+}
+
+func TestConnection_Hooks(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t)
+
+	// Each connection keeps its own hooks
+	assert.NotNil(t, connection.Hooks())
+	assert.NotSame(t, connection.Hooks(), connectionmock.New(t).Hooks())
+}
+
+func TestConnection_Metrics(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t)
+
+	assert.Empty(t, connection.Metrics())
+}
+
 func TestConnection_Migrate(t *testing.T) {
 	t.Parallel()
 
@@ -56,6 +102,20 @@ func TestConnection_Migrate_Error(t *testing.T) {
 	require.EqualError(t, err, "migration failed")
 }
 
+func TestConnection_MigrateContext(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := connection.MigrateContext(ctx, modelmock.ModelMock{})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "migration failed: context canceled")
+}
+
 func TestConnection_ORM(t *testing.T) {
 	t.Parallel()
 
@@ -64,6 +124,63 @@ func TestConnection_ORM(t *testing.T) {
 	assert.IsType(t, &gorm.DB{}, connection.ORM())
 }
 
+func TestConnection_Ping(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t)
+
+	err := connection.Ping(context.Background())
+	require.NoError(t, err)
+}
+
+func TestConnection_Ping_Error(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t)
+	connection.Fail = true
+
+	err := connection.Ping(context.Background())
+	require.Error(t, err)
+
+	require.EqualError(t, err, "ping failed")
+}
+
+func TestConnection_Plan(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t)
+
+	plan, err := connection.Plan(context.Background(), modelmock.ModelMock{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "sqlite", plan.Driver)
+	assert.Empty(t, plan.Statements)
+}
+
+func TestConnection_Plan_Error(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t)
+	connection.Fail = true
+
+	plan, err := connection.Plan(context.Background(), modelmock.ModelMock{})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "plan failed")
+	assert.Nil(t, plan)
+}
+
+func TestConnection_Stats(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t)
+
+	err := connection.Ping(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, connection.Stats().OpenConnections)
+}
+
 func TestConnection_Transaction(t *testing.T) {
 	t.Parallel()
 
@@ -89,3 +206,34 @@ func TestConnection_Transaction_Error(t *testing.T) {
 
 	require.EqualError(t, err, "invalid transaction")
 }
+
+func TestConnection_WithTransaction(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t)
+
+	err := connection.WithTransaction(context.Background(), func(tx database.Connection) error {
+		assert.IsType(t, &connectionmock.DatabaseMock{}, tx)
+		assert.NotEqual(t, connection.ORM(), tx.ORM())
+
+		mock, _ := tx.(*connectionmock.DatabaseMock)
+		assert.Same(t, connection.Hooks(), mock.Hooks())
+
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestConnection_WithTransaction_Error(t *testing.T) {
+	t.Parallel()
+
+	connection := connectionmock.New(t)
+	connection.Fail = true
+
+	err := connection.WithTransaction(context.Background(), func(_ database.Connection) error {
+		return nil
+	})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "transaction failed")
+}
@@ -1,6 +1,9 @@
 package repositorymock
 
 import (
+	"context"
+	"time"
+
 	"gorm.io/gorm"
 
 	"github.com/sjdaws/pkg/database"
@@ -8,12 +11,37 @@ import (
 
 // RepositoryMock fakes a repository.
 type RepositoryMock[m database.Model] struct {
-	CreateMock  func(model *m) error
-	DeleteMock  func(model *m, where ...any) error
-	GetMock     func(where ...any) ([]m, error)
-	OneMock     func(where ...any) (*m, error)
-	RestoreMock func(model *m) error
-	UpdateMock  func(model *m) error
+	AvgMock          func(column string, where ...any) (float64, error)
+	ChunkMock        func(size int, fn func(models []m) error, where ...any) error
+	CountMock        func(where ...any) (int64, error)
+	CreateMock       func(model *m) error
+	CreateManyMock   func(models []m, batchSize int) error
+	CursorMock       func(cursor string, size int, where ...any) (database.CursorPage[m], error)
+	DeleteMock       func(model *m, where ...any) error
+	DeleteWhereMock  func(where ...any) (int64, error)
+	EachMock         func(fn func(model m) error, where ...any) error
+	ExistsMock       func(where ...any) (bool, error)
+	ForceDeleteMock  func(model *m, where ...any) error
+	GetMock          func(where ...any) ([]m, error)
+	HistoryMock      func(model *m) ([]database.AuditEntry, error)
+	MaxMock          func(column string, where ...any) (float64, error)
+	MinMock          func(column string, where ...any) (float64, error)
+	OneMock          func(where ...any) (*m, error)
+	PaginateMock     func(page int, size int, where ...any) (database.Page[m], error)
+	PluckMock        func(column string, where ...any) ([]any, error)
+	PurgeMock        func(olderThan time.Duration, batchSize int) (int64, error)
+	RestoreMock      func(model *m) error
+	RowsMock         func(where ...any) (*database.Iterator[m], error)
+	SumMock          func(column string, where ...any) (float64, error)
+	UpdateMock       func(model *m) error
+	UpdateFieldsMock func(model *m, fields ...string) error
+	UpdateWhereMock  func(values map[string]any, where ...any) (int64, error)
+	UpsertMock       func(model *m, conflictColumns []string, updateColumns []string) error
+}
+
+// Avg run AvgMock() function.
+func (r RepositoryMock[m]) Avg(column string, where ...any) (float64, error) {
+	return r.AvgMock(column, where...)
 }
 
 // BypassDelete do nothing.
@@ -21,41 +49,154 @@ func (r RepositoryMock[m]) BypassDelete() database.Persister[m] {
 	return r
 }
 
+// BypassTenant do nothing.
+func (r RepositoryMock[m]) BypassTenant(_ string) database.Persister[m] {
+	return r
+}
+
+// Chunk run ChunkMock() function.
+func (r RepositoryMock[m]) Chunk(size int, fn func(models []m) error, where ...any) error {
+	return r.ChunkMock(size, fn, where...)
+}
+
+// Count run CountMock() function.
+func (r RepositoryMock[m]) Count(where ...any) (int64, error) {
+	return r.CountMock(where...)
+}
+
 // Create run CreateMock() function.
 func (r RepositoryMock[m]) Create(model *m) error {
 	return r.CreateMock(model)
 }
 
+// CreateMany run CreateManyMock() function.
+func (r RepositoryMock[m]) CreateMany(models []m, batchSize int) error {
+	return r.CreateManyMock(models, batchSize)
+}
+
+// Cursor run CursorMock() function.
+func (r RepositoryMock[m]) Cursor(cursor string, size int, where ...any) (database.CursorPage[m], error) {
+	return r.CursorMock(cursor, size, where...)
+}
+
 // Delete run DeleteMock() function.
 func (r RepositoryMock[m]) Delete(model *m, where ...any) error {
 	return r.DeleteMock(model, where...)
 }
 
+// DeleteWhere run DeleteWhereMock() function.
+func (r RepositoryMock[m]) DeleteWhere(where ...any) (int64, error) {
+	return r.DeleteWhereMock(where...)
+}
+
+// Each run EachMock() function.
+func (r RepositoryMock[m]) Each(fn func(model m) error, where ...any) error {
+	return r.EachMock(fn, where...)
+}
+
+// Exists run ExistsMock() function.
+func (r RepositoryMock[m]) Exists(where ...any) (bool, error) {
+	return r.ExistsMock(where...)
+}
+
+// ForceDelete run ForceDeleteMock() function.
+func (r RepositoryMock[m]) ForceDelete(model *m, where ...any) error {
+	return r.ForceDeleteMock(model, where...)
+}
+
 // Get run GetMock() function.
 func (r RepositoryMock[m]) Get(where ...any) ([]m, error) {
 	return r.GetMock(where...)
 }
 
+// History run HistoryMock() function.
+func (r RepositoryMock[m]) History(model *m) ([]database.AuditEntry, error) {
+	return r.HistoryMock(model)
+}
+
+// Hook do nothing.
+func (r RepositoryMock[m]) Hook(_ database.Event, _ database.Hook[m]) {}
+
+// Limit do nothing.
+func (r RepositoryMock[m]) Limit(_ int) database.Persister[m] {
+	return r
+}
+
+// Lock do nothing.
+func (r RepositoryMock[m]) Lock(_ database.LockMode) database.Persister[m] {
+	return r
+}
+
+// Max run MaxMock() function.
+func (r RepositoryMock[m]) Max(column string, where ...any) (float64, error) {
+	return r.MaxMock(column, where...)
+}
+
+// Min run MinMock() function.
+func (r RepositoryMock[m]) Min(column string, where ...any) (float64, error) {
+	return r.MinMock(column, where...)
+}
+
 // One run OneMock() function.
 func (r RepositoryMock[m]) One(where ...any) (*m, error) {
 	return r.OneMock(where...)
 }
 
+// OnlyDeleted do nothing.
+func (r RepositoryMock[m]) OnlyDeleted() database.Persister[m] {
+	return r
+}
+
 // OrderBy run OrderByMock() function.
 func (r RepositoryMock[m]) OrderBy(_ ...database.Order) database.Persister[m] {
 	return r
 }
 
+// Paginate run PaginateMock() function.
+func (r RepositoryMock[m]) Paginate(page int, size int, where ...any) (database.Page[m], error) {
+	return r.PaginateMock(page, size, where...)
+}
+
 // PartOf do nothing.
 func (r RepositoryMock[m]) PartOf(_ *gorm.DB) database.Persister[m] {
 	return r
 }
 
+// Pluck run PluckMock() function.
+func (r RepositoryMock[m]) Pluck(column string, where ...any) ([]any, error) {
+	return r.PluckMock(column, where...)
+}
+
+// Primary do nothing.
+func (r RepositoryMock[m]) Primary() database.Persister[m] {
+	return r
+}
+
+// Purge run PurgeMock() function.
+func (r RepositoryMock[m]) Purge(olderThan time.Duration, batchSize int) (int64, error) {
+	return r.PurgeMock(olderThan, batchSize)
+}
+
 // Restore run RestoreMock() function.
 func (r RepositoryMock[m]) Restore(model *m) error {
 	return r.RestoreMock(model)
 }
 
+// Rows run RowsMock() function.
+func (r RepositoryMock[m]) Rows(where ...any) (*database.Iterator[m], error) {
+	return r.RowsMock(where...)
+}
+
+// Subscribe do nothing, the returned function also does nothing.
+func (r RepositoryMock[m]) Subscribe(_ database.Listener[m]) func() {
+	return func() {}
+}
+
+// Sum run SumMock() function.
+func (r RepositoryMock[m]) Sum(column string, where ...any) (float64, error) {
+	return r.SumMock(column, where...)
+}
+
 // Then do nothing.
 func (r RepositoryMock[m]) Then(_ string, _ ...any) database.Persister[m] {
 	return r
@@ -66,7 +207,27 @@ func (r RepositoryMock[m]) Update(model *m) error {
 	return r.UpdateMock(model)
 }
 
+// UpdateFields run UpdateFieldsMock() function.
+func (r RepositoryMock[m]) UpdateFields(model *m, fields ...string) error {
+	return r.UpdateFieldsMock(model, fields...)
+}
+
+// UpdateWhere run UpdateWhereMock() function.
+func (r RepositoryMock[m]) UpdateWhere(values map[string]any, where ...any) (int64, error) {
+	return r.UpdateWhereMock(values, where...)
+}
+
+// Upsert run UpsertMock() function.
+func (r RepositoryMock[m]) Upsert(model *m, conflictColumns []string, updateColumns []string) error {
+	return r.UpsertMock(model, conflictColumns, updateColumns)
+}
+
 // With do nothing.
 func (r RepositoryMock[m]) With(_ string, _ ...any) database.Persister[m] {
 	return r
 }
+
+// WithContext do nothing.
+func (r RepositoryMock[m]) WithContext(_ context.Context) database.Persister[m] {
+	return r
+}
@@ -1,7 +1,9 @@
 package repositorymock_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,6 +23,29 @@ func TestRepositoryMock(t *testing.T) {
 	assert.Implements(t, (*database.Persister[modelmock.ModelMock])(nil), &repository)
 }
 
+func TestRepositoryMock_Aggregates(t *testing.T) {
+	t.Parallel()
+
+	aggregate := func(_ string, _ ...any) (float64, error) {
+		return 1, errors.New("aggregate")
+	}
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		AvgMock: aggregate,
+		MaxMock: aggregate,
+		MinMock: aggregate,
+		SumMock: aggregate,
+	}
+
+	for _, function := range []func(string, ...any) (float64, error){repository.Avg, repository.Max, repository.Min, repository.Sum} {
+		result, err := function("id")
+		require.Error(t, err)
+
+		require.EqualError(t, err, "aggregate")
+		assert.InDelta(t, 1, result, 0)
+	}
+}
+
 func TestRepositoryMock_BypassDelete(t *testing.T) {
 	t.Parallel()
 
@@ -31,6 +56,51 @@ func TestRepositoryMock_BypassDelete(t *testing.T) {
 	assert.Equal(t, repository, result)
 }
 
+func TestRepositoryMock_BypassTenant(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{}
+
+	result := repository.BypassTenant("")
+
+	assert.Equal(t, repository, result)
+}
+
+func TestRepositoryMock_Chunk(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		ChunkMock: func(_ int, fn func([]modelmock.ModelMock) error, _ ...any) error {
+			return fn([]modelmock.ModelMock{{ID: 1}})
+		},
+	}
+
+	err := repository.Chunk(10, func(models []modelmock.ModelMock) error {
+		assert.Len(t, models, 1)
+
+		return errors.New("chunk")
+	})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "chunk")
+}
+
+func TestRepositoryMock_Count(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		CountMock: func(_ ...any) (int64, error) {
+			return 2, errors.New("count")
+		},
+	}
+
+	count, err := repository.Count()
+	require.Error(t, err)
+
+	require.EqualError(t, err, "count")
+	assert.Equal(t, int64(2), count)
+}
+
 func TestRepositoryMock_Create(t *testing.T) {
 	t.Parallel()
 
@@ -47,6 +117,38 @@ func TestRepositoryMock_Create(t *testing.T) {
 	require.EqualError(t, err, "create")
 }
 
+func TestRepositoryMock_CreateMany(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		CreateManyMock: func(_ []modelmock.ModelMock, _ int) error {
+			return errors.New("create many")
+		},
+	}
+
+	err := repository.CreateMany([]modelmock.ModelMock{{ID: 1}}, 10)
+	require.Error(t, err)
+
+	require.EqualError(t, err, "create many")
+}
+
+func TestRepositoryMock_Cursor(t *testing.T) {
+	t.Parallel()
+
+	page := database.CursorPage[modelmock.ModelMock]{Items: []modelmock.ModelMock{{ID: 1}}}
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		CursorMock: func(_ string, _ int, _ ...any) (database.CursorPage[modelmock.ModelMock], error) {
+			return page, errors.New("cursor")
+		},
+	}
+
+	result, err := repository.Cursor("", 10)
+	require.Error(t, err)
+
+	require.EqualError(t, err, "cursor")
+	assert.Equal(t, page, result)
+}
+
 func TestRepositoryMock_Delete(t *testing.T) {
 	t.Parallel()
 
@@ -62,6 +164,72 @@ func TestRepositoryMock_Delete(t *testing.T) {
 	require.EqualError(t, err, "delete")
 }
 
+func TestRepositoryMock_DeleteWhere(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		DeleteWhereMock: func(_ ...any) (int64, error) {
+			return 3, errors.New("delete where")
+		},
+	}
+
+	deleted, err := repository.DeleteWhere(&modelmock.ModelMock{Test: true})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "delete where")
+	assert.Equal(t, int64(3), deleted)
+}
+
+func TestRepositoryMock_Each(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		EachMock: func(fn func(modelmock.ModelMock) error, _ ...any) error {
+			return fn(modelmock.ModelMock{ID: 1})
+		},
+	}
+
+	err := repository.Each(func(model modelmock.ModelMock) error {
+		assert.Equal(t, 1, model.ID)
+
+		return errors.New("each")
+	})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "each")
+}
+
+func TestRepositoryMock_Exists(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		ExistsMock: func(_ ...any) (bool, error) {
+			return true, errors.New("exists")
+		},
+	}
+
+	exists, err := repository.Exists()
+	require.Error(t, err)
+
+	require.EqualError(t, err, "exists")
+	assert.True(t, exists)
+}
+
+func TestRepositoryMock_ForceDelete(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		ForceDeleteMock: func(_ *modelmock.ModelMock, _ ...any) error {
+			return errors.New("force delete")
+		},
+	}
+
+	err := repository.ForceDelete(nil)
+	require.Error(t, err)
+
+	require.EqualError(t, err, "force delete")
+}
+
 func TestRepositoryMock_Get(t *testing.T) {
 	t.Parallel()
 
@@ -79,6 +247,58 @@ func TestRepositoryMock_Get(t *testing.T) {
 	assert.Equal(t, []modelmock.ModelMock{model}, get)
 }
 
+func TestRepositoryMock_History(t *testing.T) {
+	t.Parallel()
+
+	entries := []database.AuditEntry{{Action: database.AuditCreate, ID: 1}}
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		HistoryMock: func(_ *modelmock.ModelMock) ([]database.AuditEntry, error) {
+			return entries, errors.New("history")
+		},
+	}
+
+	history, err := repository.History(&modelmock.ModelMock{ID: 1})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "history")
+	assert.Equal(t, entries, history)
+}
+
+func TestRepositoryMock_Hook(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{}
+
+	repository.Hook(database.BeforeCreate, func(_ context.Context, _ *modelmock.ModelMock) error {
+		called = true
+
+		return nil
+	})
+
+	assert.False(t, called)
+}
+
+func TestRepositoryMock_Limit(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{}
+
+	result := repository.Limit(5)
+
+	assert.Equal(t, repository, result)
+}
+
+func TestRepositoryMock_Lock(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{}
+
+	result := repository.Lock(database.LockUpdate)
+
+	assert.Equal(t, repository, result)
+}
+
 func TestRepositoryMock_One(t *testing.T) {
 	t.Parallel()
 
@@ -96,6 +316,16 @@ func TestRepositoryMock_One(t *testing.T) {
 	assert.Equal(t, model, one)
 }
 
+func TestRepositoryMock_OnlyDeleted(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{}
+
+	result := repository.OnlyDeleted()
+
+	assert.Equal(t, repository, result)
+}
+
 func TestRepositoryMock_Order(t *testing.T) {
 	t.Parallel()
 
@@ -106,6 +336,23 @@ func TestRepositoryMock_Order(t *testing.T) {
 	assert.Equal(t, repository, result)
 }
 
+func TestRepositoryMock_Paginate(t *testing.T) {
+	t.Parallel()
+
+	page := database.Page[modelmock.ModelMock]{Items: []modelmock.ModelMock{{ID: 1}}, Page: 1, Pages: 1, Size: 10, Total: 1}
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		PaginateMock: func(_ int, _ int, _ ...any) (database.Page[modelmock.ModelMock], error) {
+			return page, errors.New("paginate")
+		},
+	}
+
+	result, err := repository.Paginate(1, 10)
+	require.Error(t, err)
+
+	require.EqualError(t, err, "paginate")
+	assert.Equal(t, page, result)
+}
+
 func TestRepositoryMock_PartOf(t *testing.T) {
 	t.Parallel()
 
@@ -116,6 +363,48 @@ func TestRepositoryMock_PartOf(t *testing.T) {
 	assert.Equal(t, repository, result)
 }
 
+func TestRepositoryMock_Pluck(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		PluckMock: func(_ string, _ ...any) ([]any, error) {
+			return []any{1}, errors.New("pluck")
+		},
+	}
+
+	values, err := repository.Pluck("id")
+	require.Error(t, err)
+
+	require.EqualError(t, err, "pluck")
+	assert.Equal(t, []any{1}, values)
+}
+
+func TestRepositoryMock_Primary(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{}
+
+	result := repository.Primary()
+
+	assert.Equal(t, repository, result)
+}
+
+func TestRepositoryMock_Purge(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		PurgeMock: func(_ time.Duration, _ int) (int64, error) {
+			return 2, errors.New("purge")
+		},
+	}
+
+	purged, err := repository.Purge(time.Hour, 100)
+	require.Error(t, err)
+
+	require.EqualError(t, err, "purge")
+	assert.Equal(t, int64(2), purged)
+}
+
 func TestRepositoryMock_Restore(t *testing.T) {
 	t.Parallel()
 
@@ -131,6 +420,32 @@ func TestRepositoryMock_Restore(t *testing.T) {
 	require.EqualError(t, err, "restore")
 }
 
+func TestRepositoryMock_Rows(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		RowsMock: func(_ ...any) (*database.Iterator[modelmock.ModelMock], error) {
+			return nil, errors.New("rows")
+		},
+	}
+
+	rows, err := repository.Rows()
+	require.Error(t, err)
+
+	require.EqualError(t, err, "rows")
+	assert.Nil(t, rows)
+}
+
+func TestRepositoryMock_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{}
+
+	unsubscribe := repository.Subscribe(func(_ context.Context, _ database.Change[modelmock.ModelMock]) {})
+
+	assert.NotPanics(t, unsubscribe)
+}
+
 func TestRepositoryMock_Then(t *testing.T) {
 	t.Parallel()
 
@@ -157,6 +472,52 @@ func TestRepositoryMock_Update(t *testing.T) {
 	require.EqualError(t, err, "update")
 }
 
+func TestRepositoryMock_UpdateFields(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		UpdateFieldsMock: func(_ *modelmock.ModelMock, _ ...string) error {
+			return errors.New("update fields")
+		},
+	}
+
+	err := repository.UpdateFields(&modelmock.ModelMock{ID: 1}, "Test")
+	require.Error(t, err)
+
+	require.EqualError(t, err, "update fields")
+}
+
+func TestRepositoryMock_UpdateWhere(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		UpdateWhereMock: func(_ map[string]any, _ ...any) (int64, error) {
+			return 2, errors.New("update where")
+		},
+	}
+
+	updated, err := repository.UpdateWhere(map[string]any{"test": true}, &modelmock.ModelMock{ID: 1})
+	require.Error(t, err)
+
+	require.EqualError(t, err, "update where")
+	assert.Equal(t, int64(2), updated)
+}
+
+func TestRepositoryMock_Upsert(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{
+		UpsertMock: func(_ *modelmock.ModelMock, _ []string, _ []string) error {
+			return errors.New("upsert")
+		},
+	}
+
+	err := repository.Upsert(&modelmock.ModelMock{ID: 1}, []string{"ID"}, nil)
+	require.Error(t, err)
+
+	require.EqualError(t, err, "upsert")
+}
+
 func TestRepositoryMock_With(t *testing.T) {
 	t.Parallel()
 
@@ -166,3 +527,13 @@ func TestRepositoryMock_With(t *testing.T) {
 
 	assert.Equal(t, repository, result)
 }
+
+func TestRepositoryMock_WithContext(t *testing.T) {
+	t.Parallel()
+
+	repository := repositorymock.RepositoryMock[modelmock.ModelMock]{}
+
+	result := repository.WithContext(context.Background())
+
+	assert.Equal(t, repository, result)
+}